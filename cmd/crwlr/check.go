@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/SimonRichardson/crwlr/pkg/crawler"
+	"github.com/SimonRichardson/crwlr/pkg/group"
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/SimonRichardson/crwlr/pkg/report"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCheckThreshold = 0
+)
+
+// runCheck crawls a specific addr, checking every link and asset of the
+// crawled pages can be reached.
+func runCheck(args []string) error {
+	// flags for the check command
+	var (
+		flagset = flag.NewFlagSet("check", flag.ExitOnError)
+
+		debug            = flagset.Bool("debug", false, "debug logging")
+		addr             = flagset.String("addr", defaultAddr, "addr to start crawling")
+		threshold        = flagset.Int("threshold", defaultCheckThreshold, "maximum number of broken refs before exiting with a non-zero status")
+		allowlist        = flagset.String("allowlist", "", "path to a file of urls known to be broken, one per line (* matches a prefix)")
+		reportFormat     = flagset.String("report.format", defaultReportFormat, "format to write the report in (text, junit, sarif)")
+		reportOutput     = flagset.String("report.output", "", "path to write the report to, instead of stdout")
+		followRedirects  = flagset.Bool("follow-redirects", defaultFollowRedirects, "should the crawler follow redirects")
+		userAgent        = flagset.String("useragent.full", defaultUserAgent, "full user agent the crawler should use")
+		userAgentRobot   = flagset.String("useragent.robot", defaultUserAgentRobot, "robot user agent the crawler should use")
+		filterSameDomain = flagset.Bool("filter.same-domain", defaultFilterSameDomain, "filter other domains that aren't the same")
+		canonicalize     = flagset.Bool("canonicalize", defaultCanonicalize, "rewrite urls in to a canonical form before crawling")
+		stylesheets      = flagset.Bool("stylesheets", defaultStylesheets, "parse stylesheets for the fonts, images and stylesheets they reference")
+		robotsRequest    = flagset.Bool("robots.request", defaultRobotsRequest, "request the robots.txt when crawling")
+		concurrency      = flagset.Int("concurrency", defaultConcurrency, "maximum number of requests in-flight at any one time")
+		concurrencyHost  = flagset.Int("concurrency.per-host", defaultConcurrencyPerHost, "maximum number of requests in-flight per host (0 is unlimited)")
+		maxDepth         = flagset.Int("max.depth", 0, "maximum number of hops away from the addr to crawl (0 is unlimited)")
+		maxPages         = flagset.Int("max.pages", 0, "maximum number of pages to request (0 is unlimited)")
+		maxDuration      = flagset.Duration("max.duration", 0, "maximum amount of time to spend crawling (0 is unlimited)")
+	)
+	flagset.Usage = usageFor(flagset, "check [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+	if flagset.NFlag() == 0 {
+		// Nothing found.
+		return errorFor(flagset, "check [flags]", errors.New("specify at least argument"))
+	}
+
+	// Setup the logger.
+	var logger log.Logger
+	{
+		logLevel := level.AllowInfo()
+		if *debug {
+			logLevel = level.AllowAll()
+		}
+		logger = log.NewLogfmtLogger(os.Stdout)
+		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+		logger = level.NewFilter(logger, logLevel)
+	}
+
+	// Parse the addr URL
+	u, err := url.Parse(*addr)
+	if err != nil {
+		return errorFor(flagset, "check [flags]", errors.Wrap(err, "expected valid domain"))
+	}
+
+	format, err := checkFormatFor(*reportFormat)
+	if err != nil {
+		return errorFor(flagset, "check [flags]", err)
+	}
+
+	allowed, err := allowlistFor(*allowlist)
+	if err != nil {
+		return errorFor(flagset, "check [flags]", err)
+	}
+
+	var (
+		client = clientFor(*followRedirects, *concurrencyHost)
+		agent  = peer.NewUserAgent(*userAgent, *userAgentRobot)
+		c      = crawler.NewCrawler(client, agent, *robotsRequest, false, *concurrency, *concurrencyHost, logger)
+	)
+	if *filterSameDomain {
+		c.Filter(crawler.Addr(u))
+	}
+	c.Canonicalize(canonicalizerFor(*canonicalize, defaultCanonicalParams))
+	c.Stylesheets(*stylesheets)
+	c.Limit(crawler.Limits{
+		MaxDepth:    *maxDepth,
+		MaxPages:    *maxPages,
+		MaxDuration: *maxDuration,
+	})
+
+	// Execution group.
+	var (
+		g group.Group
+		r *report.CheckReport
+	)
+	{
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			if err := c.Run(ctx, u); err != nil {
+				return err
+			}
+			checker := crawler.NewChecker(client, agent, *concurrency, log.With(logger, "component", "checker"))
+			r = c.CheckReport(ctx, checker)
+			return nil
+		}, func(error) {
+			cancel()
+		})
+	}
+	{
+		// Setup os signal interruptions.
+		cancel := make(chan struct{})
+		g.Add(func() error {
+			return interrupt(cancel)
+		}, func(error) {
+			close(cancel)
+		})
+	}
+	if err := g.Run(); err != nil {
+		return err
+	}
+
+	r.Allow(allowed)
+
+	out, err := reportOutputFor(*reportOutput)
+	if err != nil {
+		return err
+	}
+	if format == "text" {
+		writeReports(out, r)
+	} else if err := checkEncoderFor(format).Encode(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return errors.Wrap(err, "unable to write report")
+	}
+
+	if broken := len(r.Broken()); broken > *threshold {
+		return errors.Errorf("found %d broken refs, more than the threshold of %d", broken, *threshold)
+	}
+	return nil
+}
+
+// checkFormatFor returns the format to write the report of a check in.
+func checkFormatFor(format string) (string, error) {
+	switch format = strings.ToLower(format); format {
+	case "text", "junit", "sarif":
+		return format, nil
+	default:
+		return "", errors.Errorf("%s: unsupported report format", format)
+	}
+}
+
+// allowlistFor reads the report.Allowlist at path, or returns nil if there's no
+// path.
+func allowlistFor(path string) (*report.Allowlist, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open allowlist")
+	}
+	defer file.Close()
+
+	return report.ReadAllowlist(file)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/coordinator"
+	"github.com/SimonRichardson/crwlr/pkg/crawler"
+	"github.com/SimonRichardson/crwlr/pkg/group"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCoordinatorPort = 7651
+	defaultLeaseTTL        = time.Minute
+
+	// defaultCoordinatorDrain is how long the coordinator keeps serving once
+	// the crawl is done, so that polling workers find out it's done.
+	defaultCoordinatorDrain = 2 * time.Second
+)
+
+var (
+	defaultCoordinatorAddr = fmt.Sprintf("tcp://0.0.0.0:%d", defaultCoordinatorPort)
+)
+
+// runCoordinator hands out the urls of a crawl to crawl workers.
+func runCoordinator(args []string) error {
+	// flags for the coordinator command
+	var (
+		flagset = flag.NewFlagSet("coordinator", flag.ExitOnError)
+
+		debug            = flagset.Bool("debug", false, "debug logging")
+		apiAddr          = flagset.String("api", defaultCoordinatorAddr, "listen address for the coordinator API")
+		addr             = flagset.String("addr", defaultAddr, "addr to start crawling")
+		reportSitemap    = flagset.Bool("report.sitemap", defaultReportSitemap, "report the sitemap of the crawl")
+		reportMetrics    = flagset.Bool("report.metrics", defaultReportMetrics, "report the metric outcomes of the crawl")
+		filterSameDomain = flagset.Bool("filter.same-domain", defaultFilterSameDomain, "filter other domains that aren't the same")
+		canonicalize     = flagset.Bool("canonicalize", defaultCanonicalize, "rewrite urls in to a canonical form before crawling")
+		canonicalParams  = flagset.String("canonicalize.strip-params", defaultCanonicalParams, "comma separated query params to strip when canonicalizing (* matches a prefix)")
+		strategy         = flagset.String("strategy", defaultStrategy, "order to crawl urls in (bfs, dfs, priority, sitemap)")
+		leaseTTL         = flagset.Duration("lease.ttl", defaultLeaseTTL, "time a worker has to crawl a url, before it's handed out again")
+		cachePath        = flagset.String("cache.path", "", "path to store the crawl metrics on disk, instead of in memory")
+	)
+	flagset.Usage = usageFor(flagset, "coordinator [flags]")
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	// Setup the logger.
+	var logger log.Logger
+	{
+		logLevel := level.AllowInfo()
+		if *debug {
+			logLevel = level.AllowAll()
+		}
+		logger = log.NewLogfmtLogger(os.Stdout)
+		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+		logger = level.NewFilter(logger, logLevel)
+	}
+
+	// Parse the addr URL
+	u, err := url.Parse(*addr)
+	if err != nil {
+		return errorFor(flagset, "coordinator [flags]", errors.Wrap(err, "expected valid domain"))
+	}
+
+	frontier, err := frontierFor(*strategy)
+	if err != nil {
+		return errorFor(flagset, "coordinator [flags]", err)
+	}
+
+	cache := crawler.NewCache(log.With(logger, "component", "cache"))
+	if *cachePath != "" {
+		if err := os.Remove(*cachePath); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "unable to remove cache")
+		}
+		disk, err := crawler.NewDiskCache(*cachePath, log.With(logger, "component", "cache"))
+		if err != nil {
+			return errorFor(flagset, "coordinator [flags]", err)
+		}
+		defer disk.Close()
+
+		cache = disk
+	}
+
+	apiNetwork, apiAddress, err := parseAddr(*apiAddr, defaultCoordinatorPort)
+	if err != nil {
+		return err
+	}
+
+	apiListener, err := net.Listen(apiNetwork, apiAddress)
+	if err != nil {
+		return err
+	}
+	level.Debug(logger).Log("API", fmt.Sprintf("%s://%s", apiNetwork, apiAddress))
+
+	c := coordinator.NewCoordinator(frontier, cache, *leaseTTL, log.With(logger, "component", "coordinator"))
+	if *filterSameDomain {
+		c.Filter(crawler.Addr(u))
+	}
+	c.Canonicalize(canonicalizerFor(*canonicalize, *canonicalParams))
+	c.Push(u, 0)
+
+	began := time.Now()
+
+	// Execution group.
+	var g group.Group
+	{
+		cancel := make(chan struct{})
+		g.Add(func() error {
+			select {
+			case <-c.Done():
+			case <-cancel:
+				return nil
+			}
+
+			// Give the workers a chance to find out that the crawl is done.
+			select {
+			case <-time.After(defaultCoordinatorDrain):
+			case <-cancel:
+			}
+			return nil
+		}, func(error) {
+			close(cancel)
+		})
+	}
+	{
+		g.Add(func() error {
+			mux := http.NewServeMux()
+			mux.Handle("/", coordinator.NewAPI(c, log.With(logger, "component", "api")))
+			return http.Serve(apiListener, mux)
+		}, func(error) {
+			apiListener.Close()
+		})
+	}
+	{
+		cancel := make(chan struct{})
+		g.Add(func() error {
+			return interrupt(cancel)
+		}, func(error) {
+			close(cancel)
+		})
+	}
+	err = g.Run()
+
+	if *reportSitemap {
+		r, err := crawler.NewSiteReport(cache)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.Debug)
+		r.Write(w)
+		w.Flush()
+		if *reportMetrics {
+			fmt.Fprintln(os.Stdout, "")
+		}
+	}
+	if *reportMetrics {
+		r, err := crawler.NewMetricsReport(cache, time.Since(began))
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.Debug)
+		r.Write(w)
+		w.Flush()
+	}
+
+	return err
+}
@@ -2,19 +2,25 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/SimonRichardson/crwlr/pkg/coordinator"
 	"github.com/SimonRichardson/crwlr/pkg/crawler"
 	"github.com/SimonRichardson/crwlr/pkg/group"
 	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/SimonRichardson/crwlr/pkg/report"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
@@ -25,13 +31,44 @@ const (
 	defaultFilterSameDomain = true
 	defaultRobotsRequest    = true
 	defaultRobotsCrawlDelay = false
+	defaultRobotsNofollow   = false
+	defaultPolitenessDelay  = 0
 	defaultReportSitemap    = true
 	defaultReportMetrics    = false
+	defaultReportStatus     = false
+	defaultReportHops       = 3
+	defaultReportCoverage   = true
+	defaultReportFormat     = "text"
+	defaultReportSort       = "url"
+	defaultReportOrder      = "asc"
+	defaultCanonicalize     = true
+	defaultStylesheets      = true
+
+	defaultThrottle          = true
+	defaultThrottleLatency   = 2 * time.Second
+	defaultThrottleErrorRate = 0.5
+	defaultThrottleMaxDelay  = 30 * time.Second
+
+	defaultRetryStatusCodes = "408,429,500,502,503,504"
+	defaultRetryNetwork     = "timeout,reset"
+	defaultHeaders          = "Cache-Control,Content-Encoding,Server"
+
+	defaultStateInterval   = 30 * time.Second
+	defaultCoordinatorPoll = time.Second
+
+	defaultStrategy           = "bfs"
+	defaultConcurrency        = 10
+	defaultConcurrencyPerHost = 2
 
 	defaultUserAgent      = "Mozilla/5.0 (compatible; crwlr/0.1; +http://crwlr.com)"
 	defaultUserAgentRobot = "Googlebot (crwlr/0.1)"
 )
 
+var (
+	defaultRetry           = crawler.DefaultRetryPolicy()
+	defaultCanonicalParams = strings.Join(crawler.DefaultTrackingParams, ",")
+)
+
 // runCrawl crawls a specific addr.
 func runCrawl(args []string) error {
 	// flags for the crawl command
@@ -42,12 +79,50 @@ func runCrawl(args []string) error {
 		addr             = flagset.String("addr", defaultAddr, "addr to start crawling")
 		reportSitemap    = flagset.Bool("report.sitemap", defaultReportSitemap, "report the sitemap of the crawl")
 		reportMetrics    = flagset.Bool("report.metrics", defaultReportMetrics, "report the metric outcomes of the crawl")
+		reportCoverage   = flagset.Bool("report.coverage", defaultReportCoverage, "report the pages missing from the sitemap and vice versa")
+		reportStatus     = flagset.Bool("report.status", defaultReportStatus, "report the status codes and redirect chains of the crawl")
+		reportHops       = flagset.Int("report.redirect-hops", defaultReportHops, "number of redirects before a redirect chain is flagged")
+		reportFormat     = flagset.String("report.format", defaultReportFormat, "format to write the reports in (text, html, json, ndjson, csv-metrics, csv-edges, sitemap-xml, junit, sarif)")
+		reportSort       = flagset.String("report.sort", defaultReportSort, "sort the rows of the reports by (url, duration, errors, inbound)")
+		reportOrder      = flagset.String("report.order", defaultReportOrder, "order to sort the rows of the reports in (asc, desc)")
+		reportTop        = flagset.Int("report.top", 0, "maximum number of rows of the reports, after sorting (0 is unlimited)")
+		reportColumns    = flagset.String("report.columns", "", "comma separated columns of a csv report, in order (default all)")
+		reportOutput     = flagset.String("report.output", "", "path to write the reports to, instead of stdout")
+		sitemapAddr      = flagset.String("sitemap", "", "addr of a sitemap to read the urls to crawl from")
 		followRedirects  = flagset.Bool("follow-redirects", defaultFollowRedirects, "should the crawler follow redirects")
 		userAgent        = flagset.String("useragent.full", defaultUserAgent, "full user agent the crawler should use")
 		userAgentRobot   = flagset.String("useragent.robot", defaultUserAgentRobot, "robot user agent the crawler should use")
 		filterSameDomain = flagset.Bool("filter.same-domain", defaultFilterSameDomain, "filter other domains that aren't the same")
+		canonicalize     = flagset.Bool("canonicalize", defaultCanonicalize, "rewrite urls in to a canonical form before crawling")
+		canonicalParams  = flagset.String("canonicalize.strip-params", defaultCanonicalParams, "comma separated query params to strip when canonicalizing (* matches a prefix)")
+		headers          = flagset.String("headers", defaultHeaders, "comma separated response headers to record for every url")
+		stylesheets      = flagset.Bool("stylesheets", defaultStylesheets, "parse stylesheets for the fonts, images and stylesheets they reference")
 		robotsRequest    = flagset.Bool("robots.request", defaultRobotsRequest, "request the robots.txt when crawling")
 		robotsCrawlDelay = flagset.Bool("robots.crawl-delay", defaultRobotsCrawlDelay, "use the robots.txt crawl delay when crawling")
+		robotsNofollow   = flagset.Bool("robots.ignore-nofollow", defaultRobotsNofollow, "crawl the links of a page that are marked as nofollow")
+		politenessDelay  = flagset.Duration("politeness.delay", defaultPolitenessDelay, "minimum delay between requests to the same host")
+		throttle         = flagset.Bool("throttle", defaultThrottle, "slow down requests to hosts that are struggling to respond")
+		throttleLatency  = flagset.Duration("throttle.latency", defaultThrottleLatency, "response latency before a host is slowed down")
+		throttleErrors   = flagset.Float64("throttle.error-rate", defaultThrottleErrorRate, "error rate (0-1) before a host is slowed down")
+		throttleMaxDelay = flagset.Duration("throttle.max-delay", defaultThrottleMaxDelay, "maximum delay to slow down a host to, also caps any Retry-After")
+		retryAttempts    = flagset.Int("retry.max-attempts", defaultRetry.MaxAttempts, "maximum number of attempts for a request (1 disables retrying)")
+		retryBaseBackoff = flagset.Duration("retry.base-backoff", defaultRetry.BaseBackoff, "backoff before the first retry, doubled on every retry")
+		retryMaxBackoff  = flagset.Duration("retry.max-backoff", defaultRetry.MaxBackoff, "maximum backoff between retries")
+		retryJitter      = flagset.Float64("retry.jitter", defaultRetry.Jitter, "fraction (0-1) of the backoff to randomise")
+		retryStatusCodes = flagset.String("retry.status-codes", defaultRetryStatusCodes, "comma separated status codes that are retried")
+		retryNetwork     = flagset.String("retry.network", defaultRetryNetwork, "comma separated network errors that are retried (timeout, reset)")
+		strategy         = flagset.String("strategy", defaultStrategy, "order to crawl urls in (bfs, dfs, priority, sitemap)")
+		concurrency      = flagset.Int("concurrency", defaultConcurrency, "maximum number of requests in-flight at any one time")
+		concurrencyHost  = flagset.Int("concurrency.per-host", defaultConcurrencyPerHost, "maximum number of requests in-flight per host (0 is unlimited)")
+		maxDepth         = flagset.Int("max.depth", 0, "maximum number of hops away from the addr to crawl (0 is unlimited)")
+		maxPages         = flagset.Int("max.pages", 0, "maximum number of pages to request (0 is unlimited)")
+		maxDuration      = flagset.Duration("max.duration", 0, "maximum amount of time to spend crawling (0 is unlimited)")
+		maxBytes         = flagset.Int64("max.bytes", 0, "maximum number of bytes to download (0 is unlimited)")
+		coordinatorAddr  = flagset.String("coordinator", "", "addr of a coordinator to lease urls from, instead of crawling the addr")
+		coordinatorPoll  = flagset.Duration("coordinator.poll", defaultCoordinatorPoll, "interval to poll the coordinator when there are no urls to lease")
+		cachePath        = flagset.String("cache.path", "", "path to store the crawl metrics on disk, instead of in memory")
+		state            = flagset.String("state", "", "path to checkpoint the crawl to, resuming from it if it exists")
+		stateInterval    = flagset.Duration("state.interval", defaultStateInterval, "interval between checkpoints of the crawl")
 	)
 	flagset.Usage = usageFor(flagset, "crawl [flags]")
 
@@ -89,36 +164,97 @@ func runCrawl(args []string) error {
 
 	level.Debug(logger).Log("addr", *addr)
 
+	if *reportHops < 0 {
+		return errorFor(flagset, "crawl [flags]", errors.New("report.redirect-hops can't be negative"))
+	}
+
+	// Parse the coordinator URL, the coordinator then owns the crawl.
+	var (
+		coordinatorURL *url.URL
+		err            error
+	)
+	if *coordinatorAddr != "" {
+		if *state != "" {
+			return errorFor(flagset, "crawl [flags]", errors.New("state can't be used with a coordinator"))
+		}
+		if *sitemapAddr != "" {
+			return errorFor(flagset, "crawl [flags]", errors.New("sitemap can't be used with a coordinator"))
+		}
+		if coordinatorURL, err = url.Parse(*coordinatorAddr); err != nil {
+			return errorFor(flagset, "crawl [flags]", errors.Wrap(err, "expected valid coordinator"))
+		}
+	}
+
 	// Parse the addr URL
-	u, err := url.Parse(*addr)
+	var u *url.URL
+	if coordinatorURL == nil {
+		if u, err = url.Parse(*addr); err != nil {
+			return errorFor(flagset, "crawl [flags]", errors.Wrap(err, "expected valid domain"))
+		}
+	}
+
+	// Parse the sitemap URL, which can be relative to the addr.
+	var sitemapURL *url.URL
+	if *sitemapAddr != "" {
+		if sitemapURL, err = url.Parse(*sitemapAddr); err != nil {
+			return errorFor(flagset, "crawl [flags]", errors.Wrap(err, "expected valid sitemap"))
+		}
+		sitemapURL = u.ResolveReference(sitemapURL)
+	}
+
+	frontier, err := frontierFor(*strategy)
 	if err != nil {
-		return errorFor(flagset, "crawl [flags]", errors.Wrap(err, "expected valid domain"))
+		return errorFor(flagset, "crawl [flags]", err)
 	}
 
-	// Create the HTTP client that the crawler will use.
-	timeoutClient := &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			ResponseHeaderTimeout: 5 * time.Second,
-			Dial: (&net.Dialer{
-				Timeout:   10 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).Dial,
-			TLSHandshakeTimeout: 10 * time.Second,
-			DisableKeepAlives:   false,
-			MaxIdleConnsPerHost: 1,
-		},
+	format, err := reportFormatFor(*reportFormat)
+	if err != nil {
+		return errorFor(flagset, "crawl [flags]", err)
 	}
 
-	// This allows us to prevent redirects on certain domains.
-	if !*followRedirects {
-		timeoutClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
+	columns, err := reportColumnsFor(format, *reportColumns)
+	if err != nil {
+		return errorFor(flagset, "crawl [flags]", err)
 	}
 
+	sortBy, err := report.ParseSortBy(*reportSort)
+	if err != nil {
+		return errorFor(flagset, "crawl [flags]", err)
+	}
+	descending, err := reportOrderFor(*reportOrder)
+	if err != nil {
+		return errorFor(flagset, "crawl [flags]", err)
+	}
+
+	retry, err := retryPolicyFor(*retryStatusCodes, *retryNetwork)
+	if err != nil {
+		return errorFor(flagset, "crawl [flags]", err)
+	}
+	retry.MaxAttempts = *retryAttempts
+	retry.BaseBackoff = *retryBaseBackoff
+	retry.MaxBackoff = *retryMaxBackoff
+	retry.Jitter = *retryJitter
+
+	// Create the HTTP client that the crawler will use.
+	timeoutClient := clientFor(*followRedirects, *concurrencyHost)
+
 	// Execution group.
-	var g group.Group
+	var (
+		g     group.Group
+		cache *crawler.DiskCache
+	)
+	// closeCache closes the cache, which compacts and syncs it, returning err
+	// unless closing the cache fails.
+	closeCache := func(err error) error {
+		if cache == nil {
+			return err
+		}
+		e := cache.Close()
+		if cache = nil; e != nil {
+			return errors.Wrap(e, "unable to close cache")
+		}
+		return err
+	}
 	{
 		cancel := make(chan struct{})
 		g.Add(func() error {
@@ -128,37 +264,106 @@ func runCrawl(args []string) error {
 			close(cancel)
 		})
 	}
+	// Go consume the domain.
+	var (
+		agent = peer.NewUserAgent(*userAgent, *userAgentRobot)
+		c     = crawler.NewCrawler(timeoutClient, agent, *robotsRequest, *robotsCrawlDelay, *concurrency, *concurrencyHost, logger)
+		began = time.Now()
+	)
 	{
-		// Go consume the domain.
-		var (
-			agent = peer.NewUserAgent(*userAgent, *userAgentRobot)
-			c     = crawler.NewCrawler(timeoutClient, agent, *robotsRequest, *robotsCrawlDelay, logger)
-			began = time.Now()
-		)
-
-		// Filter only on the same domain i.e. don't crawl the internet.
-		if *filterSameDomain {
+		// Filter only on the same domain i.e. don't crawl the internet. When
+		// using a coordinator, the coordinator filters instead.
+		if *filterSameDomain && coordinatorURL == nil {
 			c.Filter(crawler.Addr(u))
 		}
 
-		g.Add(func() error {
-			return c.Run(u)
-		}, func(error) {
-			if *reportSitemap {
-				w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.Debug)
-				c.SiteReport().Write(w)
-				w.Flush()
-				if *reportMetrics {
-					fmt.Fprintln(os.Stdout, "")
+		c.Frontier(frontier)
+		c.Canonicalize(canonicalizerFor(*canonicalize, *canonicalParams))
+		c.Stylesheets(*stylesheets)
+		c.Headers(headersFor(*headers))
+		c.IgnoreNofollow(*robotsNofollow)
+		if sitemapURL != nil {
+			c.Sitemap(sitemapURL)
+		}
+		c.Politeness(*politenessDelay)
+		c.MaxDelay(*throttleMaxDelay)
+		if *throttle {
+			c.Throttle(crawler.NewThrottle(*throttleLatency, *throttleErrors, *throttleMaxDelay))
+		}
+		c.Retry(retry)
+		c.Limit(crawler.Limits{
+			MaxDepth:    *maxDepth,
+			MaxPages:    *maxPages,
+			MaxDuration: *maxDuration,
+			MaxBytes:    *maxBytes,
+		})
+
+		// Store the metrics on disk, keeping any previous metrics only when the
+		// state of the crawl refers to them.
+		if *cachePath != "" {
+			ref, err := stateCache(*state)
+			if err != nil {
+				return errorFor(flagset, "crawl [flags]", err)
+			}
+			if ref == "" {
+				if err := os.Remove(*cachePath); err != nil && !os.IsNotExist(err) {
+					return errors.Wrap(err, "unable to remove cache")
 				}
 			}
-			if *reportMetrics {
-				w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.Debug)
-				c.MetricsReport(time.Since(began)).Write(w)
-				w.Flush()
+			if cache, err = crawler.NewDiskCache(*cachePath, log.With(logger, "component", "cache")); err != nil {
+				return errorFor(flagset, "crawl [flags]", err)
+			}
+			// The cache is closed once the reports are written, unless
+			// returning early.
+			defer closeCache(nil)
+
+			c.Cache(cache)
+		}
+
+		// Resume from a previous crawl, in which case the addr has already
+		// been crawled.
+		seeds := []*url.URL{u}
+		if *state != "" {
+			resumed, err := resumeState(*state, c)
+			if err != nil {
+				return errorFor(flagset, "crawl [flags]", err)
+			}
+			if resumed {
+				seeds = nil
 			}
+		}
 
-			c.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			if coordinatorURL != nil {
+				client := coordinator.NewClient(http.DefaultClient, coordinatorURL)
+				worker := coordinator.NewWorker(client, c, *coordinatorPoll, log.With(logger, "component", "worker"))
+				return worker.Run(ctx, *concurrency)
+			}
+			return c.Run(ctx, seeds...)
+		}, func(error) {
+			cancel()
+		})
+	}
+	if *state != "" {
+		// Periodically checkpoint the crawl.
+		cancel := make(chan struct{})
+		g.Add(func() error {
+			ticker := time.NewTicker(*stateInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if err := saveState(*state, c); err != nil {
+						level.Warn(logger).Log("state", *state, "err", err)
+					}
+				case <-cancel:
+					return nil
+				}
+			}
+		}, func(error) {
+			close(cancel)
 		})
 	}
 	{
@@ -171,5 +376,353 @@ func runCrawl(args []string) error {
 		})
 	}
 
-	return g.Run()
+	// Run only returns once the crawler has drained all of it's workers, so
+	// it's now safe to report.
+	err = g.Run()
+
+	if *state != "" {
+		if err := saveState(*state, c); err != nil {
+			return err
+		}
+	}
+
+	// A sitemap.xml can be split in to many files, so it's written separately
+	// from the other reports.
+	if format == "sitemap-xml" {
+		r := c.SitemapXML()
+		if *reportOutput != "" {
+			if e := r.WriteFiles(*reportOutput, u); e != nil {
+				return e
+			}
+			return closeCache(err)
+		}
+		if e := r.Write(os.Stdout); e != nil {
+			return e
+		}
+		return closeCache(err)
+	}
+
+	out, e := reportOutputFor(*reportOutput)
+	if e != nil {
+		return e
+	}
+
+	switch format {
+	case "html", "json", "ndjson":
+		e = encoderFor(format).Encode(out, c.RecordReport(time.Since(began)))
+	case "junit", "sarif":
+		// Only the links and assets that were crawled are checked, the check
+		// command checks the rest.
+		e = checkEncoderFor(format).Encode(out, c.CheckReport(context.Background(), nil))
+	case "csv-metrics":
+		r := c.MetricsReport(time.Since(began))
+		orderReport(r, sortBy, descending, *reportTop)
+		e = r.WriteCSV(out, columns)
+	case "csv-edges":
+		r := c.SiteReport()
+		orderReport(r, sortBy, descending, *reportTop)
+		e = r.WriteCSV(out, columns)
+	default:
+		var reports []writer
+		if *reportSitemap {
+			r := c.SiteReport()
+			orderReport(r, sortBy, descending, *reportTop)
+			reports = append(reports, r)
+		}
+		if r := c.CoverageReport(); *reportCoverage && len(r.Unlinked())+len(r.Unlisted()) > 0 {
+			reports = append(reports, r)
+		}
+		if *reportMetrics {
+			r := c.MetricsReport(time.Since(began))
+			orderReport(r, sortBy, descending, *reportTop)
+			reports = append(reports, r)
+		}
+		if *reportStatus {
+			reports = append(reports, c.StatusReport(*reportHops))
+		}
+		writeReports(out, reports...)
+	}
+	if e != nil {
+		out.Close()
+		return e
+	}
+
+	if e := out.Close(); e != nil {
+		return errors.Wrap(e, "unable to write reports")
+	}
+
+	return closeCache(err)
+}
+
+type writer interface {
+	Write(io.Writer) error
+}
+
+// writeReports writes the reports as tables, separated by an empty line.
+func writeReports(out io.Writer, reports ...writer) {
+	for k, v := range reports {
+		if k > 0 {
+			fmt.Fprintln(out, "")
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.Debug)
+		v.Write(w)
+		w.Flush()
+	}
+}
+
+// resumeState resumes the crawler from the state file at path, returning false
+// if there is no state file.
+func resumeState(path string, c *crawler.Crawler) (bool, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "unable to open state")
+	}
+	defer file.Close()
+
+	return true, c.Resume(file)
+}
+
+// stateCache returns the path of the cache the state file at path refers to,
+// or an empty string if there is no state file or it doesn't refer to a
+// cache.
+func stateCache(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "unable to open state")
+	}
+	defer file.Close()
+
+	var state crawler.State
+	if err := json.NewDecoder(file).Decode(&state); err != nil {
+		return "", errors.Wrap(err, "invalid state")
+	}
+	return state.Cache, nil
+}
+
+// saveState checkpoints the crawler to the state file at path. The state is
+// written to a temporary file first, so a crash never leaves a partial state.
+func saveState(path string, c *crawler.Crawler) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrap(err, "unable to create state")
+	}
+	if err := c.Checkpoint(file); err != nil {
+		file.Close()
+		return errors.Wrap(err, "unable to write state")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "unable to write state")
+	}
+	return os.Rename(tmp, path)
+}
+
+// canonicalizerFor returns the crawler.Canonicalizer for a comma separated
+// list of query params, or nil if urls shouldn't be canonicalized.
+func canonicalizerFor(enabled bool, params string) *crawler.Canonicalizer {
+	if !enabled {
+		return nil
+	}
+
+	var p []string
+	for _, v := range strings.Split(params, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			p = append(p, v)
+		}
+	}
+	return crawler.NewCanonicalizer(p)
+}
+
+// clientFor returns the http.Client used for crawling, which times out
+// requests to hosts that are slow to respond.
+func clientFor(followRedirects bool, concurrencyPerHost int) *http.Client {
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: 5 * time.Second,
+			Dial: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).Dial,
+			TLSHandshakeTimeout: 10 * time.Second,
+			DisableKeepAlives:   false,
+			MaxIdleConnsPerHost: concurrencyPerHost,
+		},
+	}
+
+	// This allows us to prevent redirects on certain domains.
+	if !followRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client
+}
+
+// headersFor returns the names of the response headers to record from a comma
+// separated list.
+func headersFor(headers string) []string {
+	var res []string
+	for _, v := range strings.Split(headers, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// frontierFor returns the crawler.Frontier for a given strategy.
+func frontierFor(strategy string) (crawler.Frontier, error) {
+	switch strings.ToLower(strategy) {
+	case "bfs":
+		return crawler.NewFIFO(), nil
+	case "dfs":
+		return crawler.NewLIFO(), nil
+	case "priority":
+		return crawler.NewPriority(crawler.Shallow), nil
+	case "sitemap":
+		return crawler.NewPriority(crawler.SitemapPriority), nil
+	default:
+		return nil, errors.Errorf("%s: unsupported strategy", strategy)
+	}
+}
+
+// reportFormatFor returns the report format, which is validated before the
+// crawl so a typo doesn't waste a crawl.
+func reportFormatFor(format string) (string, error) {
+	switch format = strings.ToLower(format); format {
+	case "text", "html", "sitemap-xml", "json", "ndjson", "csv-metrics", "csv-edges", "junit", "sarif":
+		return format, nil
+	default:
+		return "", errors.Errorf("%s: unsupported report format", format)
+	}
+}
+
+// reportColumnsFor returns the comma separated columns of a csv report format.
+func reportColumnsFor(format, columns string) ([]string, error) {
+	var available []string
+	switch format {
+	case "csv-metrics":
+		available = report.MetricCSVColumns
+	case "csv-edges":
+		available = report.SiteCSVColumns
+	default:
+		if columns != "" {
+			return nil, errors.Errorf("%s: report format has no columns", format)
+		}
+		return nil, nil
+	}
+
+	var selected []string
+	if columns != "" {
+		selected = strings.Split(columns, ",")
+	}
+	return report.SelectColumns(available, selected)
+}
+
+// reportOrderFor returns if the rows of the reports are sorted in descending
+// order.
+func reportOrderFor(order string) (bool, error) {
+	switch strings.ToLower(order) {
+	case "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	default:
+		return false, errors.Errorf("%s: unsupported order (expected asc or desc)", order)
+	}
+}
+
+type orderer interface {
+	Sort(report.SortBy, bool)
+	Limit(int)
+}
+
+// orderReport sorts the rows of the report, keeping the top n rows.
+func orderReport(r orderer, by report.SortBy, descending bool, n int) {
+	r.Sort(by, descending)
+	r.Limit(n)
+}
+
+// encoderFor returns the report.Encoder for a machine-readable report format,
+// or nil if the format isn't encoded.
+func encoderFor(format string) report.Encoder {
+	switch format {
+	case "html":
+		return report.NewHTMLEncoder(false)
+	case "json":
+		return report.NewJSONEncoder()
+	case "ndjson":
+		return report.NewNDJSONEncoder()
+	default:
+		return nil
+	}
+}
+
+// checkEncoderFor returns the report.CheckEncoder for a given format.
+func checkEncoderFor(format string) report.CheckEncoder {
+	switch format {
+	case "junit":
+		return report.NewJUnitEncoder()
+	case "sarif":
+		return report.NewSARIFEncoder(version)
+	default:
+		return nil
+	}
+}
+
+// reportOutputFor returns the file at path to write the reports to, or stdout
+// if there is no path.
+func reportOutputFor(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create report output")
+	}
+	return file, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// retryPolicyFor returns a crawler.RetryPolicy for the comma separated status
+// codes and network errors.
+func retryPolicyFor(statusCodes, network string) (crawler.RetryPolicy, error) {
+	var policy crawler.RetryPolicy
+	for _, v := range strings.Split(statusCodes, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		code, err := strconv.Atoi(v)
+		if err != nil {
+			return policy, errors.Errorf("%s: invalid status code", v)
+		}
+		policy.StatusCodes = append(policy.StatusCodes, code)
+	}
+
+	for _, v := range strings.Split(network, ",") {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "":
+		case "timeout":
+			policy.Timeouts = true
+		case "reset":
+			policy.Resets = true
+		default:
+			return policy, errors.Errorf("%s: unsupported network error", v)
+		}
+	}
+	return policy, nil
 }
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"sync"
 	"testing"
 
@@ -16,7 +18,7 @@ import (
 func benchmarkCrawl(local bool, b *testing.B) {
 	var (
 		agent = peer.NewUserAgent(defaultUserAgent, defaultUserAgentRobot)
-		c     = crawler.NewCrawler(http.DefaultClient, agent, true, false, log.NewNopLogger())
+		c     = crawler.NewCrawler(http.DefaultClient, agent, true, false, defaultConcurrency, defaultConcurrencyPerHost, log.NewNopLogger())
 
 		server = httptest.NewServer(static.NewAPI(local, log.NewNopLogger()))
 
@@ -38,7 +40,7 @@ func benchmarkCrawl(local bool, b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		go func() {
 			wg.Done()
-			if err := c.Run(u); err != nil {
+			if err := c.Run(context.Background(), u); err != nil {
 				b.Error(err)
 			}
 		}()
@@ -48,3 +50,71 @@ func benchmarkCrawl(local bool, b *testing.B) {
 }
 
 func BenchmarkCrawl_NonLocal(b *testing.B) { benchmarkCrawl(false, b) }
+
+func TestFrontierFor(t *testing.T) {
+	for _, testcase := range []struct {
+		strategy string
+		valid    bool
+	}{
+		{"bfs", true},
+		{"dfs", true},
+		{"PRIORITY", true},
+		{"random", false},
+	} {
+		_, err := frontierFor(testcase.strategy)
+		if testcase.valid != (err == nil) {
+			t.Errorf("(%q): want valid %t, have %v", testcase.strategy, testcase.valid, err)
+		}
+	}
+}
+
+func TestRetryPolicyFor(t *testing.T) {
+	for _, testcase := range []struct {
+		statusCodes, network string
+		codes                []int
+		timeouts, resets     bool
+		valid                bool
+	}{
+		{"", "", nil, false, false, true},
+		{"429, 503", "timeout", []int{429, 503}, true, false, true},
+		{"500", "timeout,reset", []int{500}, true, true, true},
+		{"abc", "", nil, false, false, false},
+		{"", "dns", nil, false, false, false},
+	} {
+		policy, err := retryPolicyFor(testcase.statusCodes, testcase.network)
+		if testcase.valid != (err == nil) {
+			t.Errorf("(%q, %q): want valid %t, have %v", testcase.statusCodes, testcase.network, testcase.valid, err)
+			continue
+		}
+		if !testcase.valid {
+			continue
+		}
+		if !reflect.DeepEqual(testcase.codes, policy.StatusCodes) || testcase.timeouts != policy.Timeouts || testcase.resets != policy.Resets {
+			t.Errorf("(%q, %q): want [%v %t %t], have [%v %t %t]",
+				testcase.statusCodes, testcase.network,
+				testcase.codes, testcase.timeouts, testcase.resets,
+				policy.StatusCodes, policy.Timeouts, policy.Resets,
+			)
+		}
+	}
+}
+
+func TestCanonicalizerFor(t *testing.T) {
+	for _, testcase := range []struct {
+		enabled       bool
+		params, input string
+		want          string
+	}{
+		{false, "utm_*", "http://A.com/?utm_source=x", "http://A.com/?utm_source=x"},
+		{true, "", "http://A.com/?utm_source=x", "http://a.com/?utm_source=x"},
+		{true, "utm_*, sid", "http://A.com/?utm_source=x&sid=1&a=b", "http://a.com/?a=b"},
+	} {
+		u, err := url.Parse(testcase.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := canonicalizerFor(testcase.enabled, testcase.params).Canonicalize(u).String(); testcase.want != have {
+			t.Errorf("(%t, %q): want %q, have %q", testcase.enabled, testcase.params, testcase.want, have)
+		}
+	}
+}
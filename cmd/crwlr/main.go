@@ -43,6 +43,10 @@ func main() {
 		cmd = runStatic
 	case "crawl":
 		cmd = runCrawl
+	case "check":
+		cmd = runCheck
+	case "coordinator":
+		cmd = runCoordinator
 	default:
 		usage()
 	}
@@ -55,8 +59,10 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "  %s <mode> [flags]\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "\n")
 	fmt.Fprintf(os.Stderr, "MODES\n")
-	fmt.Fprintf(os.Stderr, "  crawl      Crawling service\n")
-	fmt.Fprintf(os.Stderr, "  static     Static template site for crawling\n")
+	fmt.Fprintf(os.Stderr, "  crawl        Crawling service\n")
+	fmt.Fprintf(os.Stderr, "  check        Checks a site for broken links and assets\n")
+	fmt.Fprintf(os.Stderr, "  coordinator  Coordinates crawling between crawl services\n")
+	fmt.Fprintf(os.Stderr, "  static       Static template site for crawling\n")
 	fmt.Fprintf(os.Stderr, "\n")
 	fmt.Fprintf(os.Stderr, "VERSION\n")
 	fmt.Fprintf(os.Stderr, "  %s (%s)\n", version, runtime.Version())
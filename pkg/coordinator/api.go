@@ -0,0 +1,90 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	// APIPathLease is the path workers lease urls from.
+	APIPathLease = "/lease"
+	// APIPathComplete is the path workers report results to.
+	APIPathComplete = "/complete"
+)
+
+// API serves the Coordinator over HTTP.
+//
+// POST /lease returns a Lease as JSON, or 204 if there are no urls available
+// right now and 410 once the crawl is done.
+// POST /complete accepts a Result as JSON, returning 409 if the lease expired.
+type API struct {
+	coordinator *Coordinator
+	logger      log.Logger
+}
+
+// NewAPI returns a usable API for a Coordinator.
+func NewAPI(coordinator *Coordinator, logger log.Logger) *API {
+	return &API{
+		coordinator: coordinator,
+		logger:      logger,
+	}
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case APIPathLease:
+		a.handleLease(w, r)
+	case APIPathComplete:
+		a.handleComplete(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (a *API) handleLease(w http.ResponseWriter, r *http.Request) {
+	lease, err := a.coordinator.Lease()
+	switch err {
+	case nil:
+	case ErrPending:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case ErrDone:
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Debug(a.logger).Log("lease", lease.ID, "url", lease.URL, "remote", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lease); err != nil {
+		level.Error(a.logger).Log("lease", lease.ID, "err", err)
+	}
+}
+
+func (a *API) handleComplete(w http.ResponseWriter, r *http.Request) {
+	var result Result
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch err := a.coordinator.Complete(result); err {
+	case nil:
+		w.WriteHeader(http.StatusOK)
+	case ErrExpired:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
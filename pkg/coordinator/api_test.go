@@ -0,0 +1,40 @@
+package coordinator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestAPI(t *testing.T) {
+	t.Parallel()
+
+	api := NewAPI(newCoordinator(time.Minute), log.NewNopLogger())
+
+	for _, v := range []struct {
+		name, method, path, body string
+		status                   int
+	}{
+		{"lease done", "POST", APIPathLease, "", http.StatusGone},
+		{"lease method", "GET", APIPathLease, "", http.StatusMethodNotAllowed},
+		{"complete expired", "POST", APIPathComplete, `{"id":"1"}`, http.StatusConflict},
+		{"complete invalid", "POST", APIPathComplete, `{`, http.StatusBadRequest},
+		{"not found", "POST", "/bad", "", http.StatusNotFound},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			var (
+				req = httptest.NewRequest(v.method, v.path, strings.NewReader(v.body))
+				rec = httptest.NewRecorder()
+			)
+			api.ServeHTTP(rec, req)
+
+			if expected, actual := v.status, rec.Code; expected != actual {
+				t.Errorf("expected: %d, actual: %d", expected, actual)
+			}
+		})
+	}
+}
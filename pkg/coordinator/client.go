@@ -0,0 +1,91 @@
+package coordinator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Client talks to a Coordinator API.
+type Client struct {
+	client *http.Client
+	addr   *url.URL
+}
+
+// NewClient creates a Client for the Coordinator API at addr.
+func NewClient(client *http.Client, addr *url.URL) *Client {
+	return &Client{
+		client: client,
+		addr:   addr,
+	}
+}
+
+// Lease a url from the Coordinator. The same errors as Coordinator.Lease are
+// returned, so the Client can be used in place of a Coordinator.
+func (c *Client) Lease(ctx context.Context) (Lease, error) {
+	var lease Lease
+	resp, err := c.post(ctx, APIPathLease, nil)
+	if err != nil {
+		return lease, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return lease, ErrPending
+	case http.StatusGone:
+		return lease, ErrDone
+	default:
+		return lease, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+		return lease, errors.Wrap(err, "invalid lease")
+	}
+	return lease, nil
+}
+
+// Complete reports the result of a lease to the Coordinator.
+func (c *Client) Complete(ctx context.Context, r Result) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "invalid result")
+	}
+
+	resp, err := c.post(ctx, APIPathComplete, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return ErrExpired
+	default:
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func (c *Client) post(ctx context.Context, path string, body io.Reader) (*http.Response, error) {
+	u := c.addr.ResolveReference(&url.URL{Path: path})
+	req, err := http.NewRequest("POST", u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to reach coordinator")
+	}
+	return resp, nil
+}
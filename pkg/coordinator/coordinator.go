@@ -0,0 +1,224 @@
+package coordinator
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/crawler"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrPending is returned when there are no urls to lease, but there are
+	// still leases outstanding that could discover more urls.
+	ErrPending = errors.New("no urls available")
+
+	// ErrDone is returned when the crawl has completed.
+	ErrDone = errors.New("crawl done")
+
+	// ErrExpired is returned when a lease is completed after it has expired.
+	ErrExpired = errors.New("lease expired")
+)
+
+// Lease is a url that has been handed out to a worker to crawl. If the lease
+// isn't completed before it expires, the url is handed out again.
+type Lease struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Depth   int       `json:"depth"`
+	Expires time.Time `json:"expires"`
+}
+
+// Result is reported by a worker once the url of a lease has been crawled.
+type Result struct {
+	ID     string               `json:"id"`
+	Metric *crawler.StateMetric `json:"metric,omitempty"`
+	Links  []crawler.StateItem  `json:"links"`
+}
+
+// Coordinator owns the frontier and the seen set of a crawl, handing out urls
+// to workers as leases so that a crawl can be split between processes.
+type Coordinator struct {
+	mutex         sync.Mutex
+	frontier      crawler.Frontier
+	seen          map[string]struct{}
+	leases        map[string]leased
+	filters       []crawler.Filter
+	canonicalizer *crawler.Canonicalizer
+	cache         crawler.Cache
+	ttl           time.Duration
+	seq           uint64
+	done          chan struct{}
+	logger        log.Logger
+}
+
+type leased struct {
+	item    crawler.Item
+	expires time.Time
+}
+
+// NewCoordinator creates a Coordinator that stores the metrics reported by the
+// workers in the cache. Leases that aren't completed with in the ttl are
+// handed out again.
+func NewCoordinator(frontier crawler.Frontier, cache crawler.Cache, ttl time.Duration, logger log.Logger) *Coordinator {
+	return &Coordinator{
+		frontier: frontier,
+		seen:     map[string]struct{}{},
+		leases:   map[string]leased{},
+		filters:  []crawler.Filter{},
+		cache:    cache,
+		ttl:      ttl,
+		done:     make(chan struct{}),
+		logger:   logger,
+	}
+}
+
+// Filter adds a filter for the urls that are discovered by the workers.
+// Note: Filter should be called before Push.
+func (c *Coordinator) Filter(f crawler.Filter) {
+	c.filters = append(c.filters, f)
+}
+
+// Canonicalize defines how urls are rewritten in to a canonical form, before
+// they're checked against the seen set.
+// Note: Canonicalize should be called before Push.
+func (c *Coordinator) Canonicalize(canonicalizer *crawler.Canonicalizer) {
+	c.canonicalizer = canonicalizer
+}
+
+// Push adds a url to the crawl, if the url hasn't already been seen.
+func (c *Coordinator) Push(u *url.URL, depth int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.push(u, depth)
+}
+
+// Lease hands out the next url to crawl. ErrPending is returned if there are
+// no urls to crawl right now and ErrDone once the crawl has completed.
+func (c *Coordinator) Lease() (Lease, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	c.expire(now)
+
+	i, ok := c.frontier.Pop()
+	if !ok {
+		if len(c.leases) == 0 {
+			c.close()
+			return Lease{}, ErrDone
+		}
+		return Lease{}, ErrPending
+	}
+
+	c.seq++
+	l := Lease{
+		ID:      strconv.FormatUint(c.seq, 10),
+		URL:     i.URL.String(),
+		Depth:   i.Depth,
+		Expires: now.Add(c.ttl),
+	}
+	c.leases[l.ID] = leased{
+		item:    i,
+		expires: l.Expires,
+	}
+	return l, nil
+}
+
+// Complete records the result of a lease, adding any links that haven't been
+// seen to the crawl. ErrExpired is returned if the lease has already expired,
+// in which case the result is discarded.
+func (c *Coordinator) Complete(r Result) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.expire(time.Now())
+
+	l, ok := c.leases[r.ID]
+	if !ok {
+		return ErrExpired
+	}
+	delete(c.leases, r.ID)
+
+	for _, v := range r.Links {
+		u, err := url.Parse(v.URL)
+		if err != nil {
+			level.Warn(c.logger).Log("url", v.URL, "err", err)
+			continue
+		}
+		c.push(u, v.Depth)
+	}
+
+	if r.Metric != nil {
+		// Links outside of the crawl are dropped, as a crawler would have
+		// done so itself.
+		links := []string{}
+		for _, v := range r.Metric.RefLinks {
+			if u, err := url.Parse(v); err == nil && c.filtered(u) {
+				links = append(links, v)
+			}
+		}
+		r.Metric.RefLinks = links
+
+		c.cache.Set(l.item.URL.String(), r.Metric.Metric())
+	}
+
+	if c.frontier.Len() == 0 && len(c.leases) == 0 {
+		c.close()
+	}
+	return nil
+}
+
+// Done returns a channel that's closed once the crawl has completed.
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// push adds a url to the frontier if it's valid and hasn't been seen.
+// Note: the mutex should be held when calling push.
+func (c *Coordinator) push(u *url.URL, depth int) {
+	u = c.canonicalizer.Canonicalize(u)
+	str := u.String()
+	if _, ok := c.seen[str]; ok || !c.filtered(u) {
+		return
+	}
+	c.seen[str] = struct{}{}
+	c.frontier.Push(crawler.Item{URL: u, Depth: depth})
+}
+
+// expire puts any expired leases back on to the frontier.
+// Note: the mutex should be held when calling expire.
+func (c *Coordinator) expire(now time.Time) {
+	for k, v := range c.leases {
+		if now.After(v.expires) {
+			level.Debug(c.logger).Log("lease", k, "url", v.item.URL.String(), "state", "expired")
+
+			delete(c.leases, k)
+			c.frontier.Push(v.item)
+		}
+	}
+}
+
+// close marks the crawl as done. It's safe to call close multiple times.
+// Note: the mutex should be held when calling close.
+func (c *Coordinator) close() {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+}
+
+func (c *Coordinator) filtered(u *url.URL) bool {
+	for _, v := range c.filters {
+		if !v.Valid(u) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package coordinator
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/crawler"
+	"github.com/go-kit/kit/log"
+)
+
+func newCoordinator(ttl time.Duration) *Coordinator {
+	logger := log.NewNopLogger()
+	return NewCoordinator(crawler.NewFIFO(), crawler.NewCache(logger), ttl, logger)
+}
+
+func TestCoordinator(t *testing.T) {
+	t.Parallel()
+
+	u, _ := url.Parse("http://a.com")
+
+	t.Run("lease", func(t *testing.T) {
+		c := newCoordinator(time.Minute)
+		c.Push(u, 0)
+
+		lease, err := c.Lease()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := u.String(), lease.URL; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+
+		if _, err := c.Lease(); err != ErrPending {
+			t.Errorf("expected: %v, actual: %v", ErrPending, err)
+		}
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		c := newCoordinator(time.Minute)
+		c.Push(u, 0)
+
+		lease, err := c.Lease()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		metric := crawler.NewStateMetric(crawler.NewMetric())
+		metric.Received = 1
+		err = c.Complete(Result{
+			ID:     lease.ID,
+			Metric: &metric,
+			Links: []crawler.StateItem{
+				{URL: "http://a.com/b", Depth: 1},
+				{URL: "http://a.com", Depth: 1},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := c.cache.Get(u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(1), m.Received.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+
+		// Only the unseen link should be leased.
+		lease, err = c.Lease()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := "http://a.com/b", lease.URL; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+		if expected, actual := 1, lease.Depth; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		c := newCoordinator(time.Minute)
+		c.Filter(crawler.Addr(u))
+		c.Push(u, 0)
+
+		lease, _ := c.Lease()
+		metric := crawler.NewStateMetric(crawler.NewMetric())
+		metric.RefLinks = []string{"http://b.com"}
+		c.Complete(Result{
+			ID:     lease.ID,
+			Metric: &metric,
+			Links:  []crawler.StateItem{{URL: "http://b.com", Depth: 1}},
+		})
+
+		if _, err := c.Lease(); err != ErrDone {
+			t.Errorf("expected: %v, actual: %v", ErrDone, err)
+		}
+		m, err := c.cache.Get(u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := 0, len(m.RefLinks); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		c := newCoordinator(time.Millisecond)
+		c.Push(u, 0)
+
+		first, err := c.Lease()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		second, err := c.Lease()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := first.URL, second.URL; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+		if err := c.Complete(Result{ID: first.ID}); err != ErrExpired {
+			t.Errorf("expected: %v, actual: %v", ErrExpired, err)
+		}
+	})
+
+	t.Run("done", func(t *testing.T) {
+		c := newCoordinator(time.Minute)
+		c.Push(u, 0)
+
+		lease, _ := c.Lease()
+		if err := c.Complete(Result{ID: lease.ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-c.Done():
+		default:
+			t.Error("expected crawl to be done")
+		}
+		if _, err := c.Lease(); err != ErrDone {
+			t.Errorf("expected: %v, actual: %v", ErrDone, err)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package coordinator
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/crawler"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// maxFailures is the number of consecutive failures to reach the coordinator
+// before a worker gives up.
+const maxFailures = 5
+
+// Worker crawls the urls leased from a Coordinator, reporting the results back
+// to the Coordinator instead of crawling the urls it discovers.
+type Worker struct {
+	client  *Client
+	crawler *crawler.Crawler
+	poll    time.Duration
+	logger  log.Logger
+}
+
+// NewWorker creates a Worker that visits the leased urls with the crawler.
+// The poll is how long to wait before asking for another lease, when there
+// are no urls available.
+func NewWorker(client *Client, c *crawler.Crawler, poll time.Duration, logger log.Logger) *Worker {
+	return &Worker{
+		client:  client,
+		crawler: c,
+		poll:    poll,
+		logger:  logger,
+	}
+}
+
+// Run leases urls with the given concurrency, until the crawl is done or the
+// context is done. Any leases that are outstanding when the context is done
+// will expire, so they'll be handed out to another worker.
+func (w *Worker) Run(ctx context.Context, concurrency int) error {
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		res  error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := w.work(ctx); err != nil {
+				once.Do(func() {
+					res = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	return res
+}
+
+func (w *Worker) work(ctx context.Context) error {
+	var failures int
+	for {
+		lease, err := w.client.Lease(ctx)
+		switch {
+		case err == nil:
+			failures = 0
+		case err == ErrDone:
+			return nil
+		case ctx.Err() != nil:
+			return &crawler.CancelledError{Err: ctx.Err()}
+		case err == ErrPending:
+			failures = 0
+			if err := w.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		default:
+			if failures++; failures >= maxFailures {
+				return err
+			}
+			level.Warn(w.logger).Log("coordinator", "lease", "err", err)
+			if err := w.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := w.visit(ctx, lease)
+		if err != nil {
+			level.Warn(w.logger).Log("lease", lease.ID, "url", lease.URL, "err", err)
+			continue
+		}
+		if ctx.Err() != nil {
+			// The visit never completed, so let the lease expire.
+			return &crawler.CancelledError{Err: ctx.Err()}
+		}
+
+		switch err := w.client.Complete(ctx, result); err {
+		case nil:
+		case ErrExpired:
+			level.Warn(w.logger).Log("lease", lease.ID, "url", lease.URL, "err", err)
+		default:
+			level.Warn(w.logger).Log("coordinator", "complete", "err", err)
+		}
+	}
+}
+
+// visit crawls the url of a lease, returning the Result for the Coordinator.
+func (w *Worker) visit(ctx context.Context, lease Lease) (Result, error) {
+	u, err := url.Parse(lease.URL)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "invalid lease url")
+	}
+
+	metric, items := w.crawler.Visit(ctx, crawler.Item{
+		URL:   u,
+		Depth: lease.Depth,
+	})
+
+	result := Result{
+		ID:    lease.ID,
+		Links: make([]crawler.StateItem, len(items)),
+	}
+	if metric != nil {
+		m := crawler.NewStateMetric(metric)
+		result.Metric = &m
+	}
+	for k, v := range items {
+		result.Links[k] = crawler.StateItem{
+			URL:   v.URL.String(),
+			Depth: v.Depth,
+		}
+	}
+	return result, nil
+}
+
+func (w *Worker) wait(ctx context.Context) error {
+	timer := time.NewTimer(w.poll)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return &crawler.CancelledError{Err: ctx.Err()}
+	}
+}
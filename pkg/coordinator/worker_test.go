@@ -0,0 +1,131 @@
+package coordinator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/crawler"
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/SimonRichardson/crwlr/pkg/static"
+	"github.com/go-kit/kit/log"
+)
+
+func TestWorker(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logger = log.NewNopLogger()
+		agent  = peer.NewUserAgent("", "")
+		site   = httptest.NewServer(static.NewAPI(false, logger))
+	)
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("split", func(t *testing.T) {
+		cache := crawler.NewCache(logger)
+		c := NewCoordinator(crawler.NewFIFO(), cache, time.Minute, logger)
+		c.Filter(crawler.Addr(u))
+		c.Push(u, 0)
+
+		api := httptest.NewServer(NewAPI(c, logger))
+		defer api.Close()
+
+		addr, err := url.Parse(api.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Split the crawl between two workers.
+		var (
+			wg   sync.WaitGroup
+			errs = make(chan error, 2)
+		)
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				crwlr := crawler.NewCrawler(http.DefaultClient, agent, false, false, 2, 2, logger)
+				worker := NewWorker(NewClient(http.DefaultClient, addr), crwlr, time.Millisecond, logger)
+				errs <- worker.Run(context.Background(), 2)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Error(err)
+			}
+		}
+
+		select {
+		case <-c.Done():
+		default:
+			t.Error("expected crawl to be done")
+		}
+		if !cache.Exists(fmt.Sprintf("%s/page2", u.String())) {
+			t.Error("expected to have crawled page2")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		c := NewCoordinator(crawler.NewFIFO(), crawler.NewCache(logger), time.Minute, logger)
+		api := httptest.NewServer(NewAPI(c, logger))
+		defer api.Close()
+
+		addr, err := url.Parse(api.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Hold a lease, so the worker is left waiting for urls.
+		c.Push(u, 0)
+		if _, err := c.Lease(); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		crwlr := crawler.NewCrawler(http.DefaultClient, agent, false, false, 1, 1, logger)
+		worker := NewWorker(NewClient(http.DefaultClient, addr), crwlr, time.Millisecond, logger)
+		if _, ok := worker.Run(ctx, 1).(*crawler.CancelledError); !ok {
+			t.Error("expected cancelled error")
+		}
+	})
+
+	t.Run("leased again", func(t *testing.T) {
+		crwlr := crawler.NewCrawler(http.DefaultClient, agent, false, false, 1, 1, logger)
+		crwlr.Filter(crawler.Addr(u))
+		worker := NewWorker(nil, crwlr, time.Millisecond, logger)
+
+		// The result of the first lease expired, so the url is leased again.
+		lease := Lease{ID: "a", URL: u.String()}
+		first, err := worker.visit(context.Background(), lease)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := worker.visit(context.Background(), lease)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(first.Links) == 0 {
+			t.Fatal("expected links")
+		}
+		if expected, actual := len(first.Links), len(second.Links); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+}
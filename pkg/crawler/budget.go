@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Limits defines the budget of a crawl. A zero value for any of the limits
+// means that it's unlimited.
+type Limits struct {
+	// MaxDepth is the maximum number of hops away from the seed url.
+	MaxDepth int
+	// MaxPages is the maximum number of pages that will be requested.
+	MaxPages int
+	// MaxDuration is the maximum amount of time to spend crawling.
+	MaxDuration time.Duration
+	// MaxBytes is the maximum number of body bytes that will be downloaded.
+	MaxBytes int64
+}
+
+// budget keeps track of how much of the Limits have been used up.
+type budget struct {
+	limits  Limits
+	began   time.Time
+	elapsed time.Duration
+	pages   int64
+	bytes   int64
+}
+
+func newBudget(limits Limits) *budget {
+	return &budget{
+		limits: limits,
+		began:  time.Now(),
+	}
+}
+
+// Start resets the duration of the budget to begin now, less any time that has
+// already been restored.
+func (b *budget) Start() {
+	b.began = time.Now().Add(-b.elapsed)
+}
+
+// Used returns how many pages and bytes have been taken from the budget and
+// how long the budget has been running for.
+func (b *budget) Used() (pages, bytes int64, elapsed time.Duration) {
+	return atomic.LoadInt64(&b.pages), atomic.LoadInt64(&b.bytes), time.Since(b.began)
+}
+
+// Restore sets how much of the budget has already been used, for example by
+// a crawl that's being resumed.
+// Note: Restore should be called before Start.
+func (b *budget) Restore(pages, bytes int64, elapsed time.Duration) {
+	atomic.StoreInt64(&b.pages, pages)
+	atomic.StoreInt64(&b.bytes, bytes)
+	b.elapsed = elapsed
+}
+
+// Depth returns if the depth is with in budget.
+func (b *budget) Depth(depth int) bool {
+	return b.limits.MaxDepth < 1 || depth <= b.limits.MaxDepth
+}
+
+// Take attempts to take a page from the budget, returning false if there is
+// no longer any budget left.
+func (b *budget) Take() bool {
+	if max := b.limits.MaxDuration; max > 0 && time.Since(b.began) > max {
+		return false
+	}
+	if max := b.limits.MaxBytes; max > 0 && atomic.LoadInt64(&b.bytes) >= max {
+		return false
+	}
+	if max := int64(b.limits.MaxPages); max > 0 && atomic.AddInt64(&b.pages, 1) > max {
+		return false
+	}
+	return true
+}
+
+// Consume records the amount of bytes downloaded against the budget.
+func (b *budget) Consume(n int) {
+	atomic.AddInt64(&b.bytes, int64(n))
+}
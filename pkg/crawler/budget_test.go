@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unlimited", func(t *testing.T) {
+		fn := func(a uint) bool {
+			b := newBudget(Limits{})
+			b.Consume(int(a % 1e6))
+			return b.Depth(int(a%1000)) && b.Take()
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("depth", func(t *testing.T) {
+		fn := func(a uint) bool {
+			var (
+				depth = int(a%1000) + 1
+				b     = newBudget(Limits{MaxDepth: depth})
+			)
+			return b.Depth(depth) && !b.Depth(depth+1)
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		fn := func(a uint) bool {
+			var (
+				pages = int(a%1000) + 1
+				b     = newBudget(Limits{MaxPages: pages})
+			)
+			for i := 0; i < pages; i++ {
+				if !b.Take() {
+					return false
+				}
+			}
+			return !b.Take()
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		b := newBudget(Limits{MaxBytes: 10})
+		if !b.Take() {
+			t.Error("expected budget to be available")
+		}
+
+		b.Consume(10)
+		if b.Take() {
+			t.Error("expected budget to be exhausted")
+		}
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		b := newBudget(Limits{MaxDuration: time.Millisecond})
+		b.Start()
+		if !b.Take() {
+			t.Error("expected budget to be available")
+		}
+
+		time.Sleep(time.Millisecond * 2)
+		if b.Take() {
+			t.Error("expected budget to be exhausted")
+		}
+	})
+}
@@ -5,30 +5,54 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/SimonRichardson/crwlr/pkg/document"
 	"github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 	"github.com/temoto/robotstxt"
 )
 
-// Cache returns a metric cache of urls visited along with misses, errors and
+// Cache stores the metrics of urls visited along with misses, errors and
 // duration of the request.
-type Cache struct {
+// Note: a Metric retrieved from the Cache isn't guaranteed to be the same
+// Metric that's held by the Cache, so any changes must be Set again, or made
+// with Update when other workers could be changing the same Metric.
+type Cache interface {
+	// Exists returns truthy if the value exists
+	Exists(v string) bool
+
+	// Get a cache metric value based on the value.
+	Get(v string) (*Metric, error)
+
+	// Set a cache metric based on the value.
+	// Note: if the metric already exists it will over write it.
+	Set(v string, m *Metric)
+
+	// Update calls fn with the metric of the value, or a new metric if there
+	// isn't one, then stores the metric. No other Set or Update of the value
+	// can happen whilst fn is called, so no changes are lost.
+	Update(v string, fn func(m *Metric, exists bool))
+
+	// Range calls fn for every metric in the cache, stopping if fn returns
+	// false. No metrics can be Set until Range returns.
+	Range(fn func(v string, m *Metric) bool) error
+}
+
+type memory struct {
 	mutex   sync.RWMutex
 	metrics map[string]*Metric
 	logger  log.Logger
 }
 
-// NewCache returns a cache
-func NewCache(logger log.Logger) *Cache {
-	return &Cache{
+// NewCache returns a cache that holds all the metrics in memory.
+func NewCache(logger log.Logger) Cache {
+	return &memory{
 		mutex:   sync.RWMutex{},
 		metrics: map[string]*Metric{},
 		logger:  logger,
 	}
 }
 
-// Exists returns truthy if the value exists
-func (c *Cache) Exists(v string) bool {
+func (c *memory) Exists(v string) bool {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
@@ -36,8 +60,7 @@ func (c *Cache) Exists(v string) bool {
 	return ok
 }
 
-// Get a cache metric value based on the value.
-func (c *Cache) Get(v string) (*Metric, error) {
+func (c *memory) Get(v string) (*Metric, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
@@ -48,23 +71,69 @@ func (c *Cache) Get(v string) (*Metric, error) {
 	return m, nil
 }
 
-// Set a cache metric based on the value.
-// Note: if the metric already exists it will over write it.
-func (c *Cache) Set(v string, m *Metric) {
+func (c *memory) Set(v string, m *Metric) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	c.metrics[v] = m
 }
 
+func (c *memory) Update(v string, fn func(*Metric, bool)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	m, ok := c.metrics[v]
+	if !ok {
+		m = NewMetric()
+	}
+	fn(m, ok)
+	c.metrics[v] = m
+}
+
+func (c *memory) Range(fn func(string, *Metric) bool) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for k, v := range c.metrics {
+		if !fn(k, v) {
+			break
+		}
+	}
+	return nil
+}
+
 // Metric holds some very simple primitive metric values for reporting.
 type Metric struct {
 	mutex                   sync.Mutex
 	Requested, Received     *Clock
 	Filtered, Errorred      *Clock
+	Skipped, Retried        *Clock
 	Duration                time.Duration
 	Robots                  *robotstxt.RobotsData
 	RefLinks, RefAssetLinks []string
+	RefKinds                map[string]document.Kind
+	NoIndex                 bool
+	StatusCode              int
+	ContentLength           int64
+	Headers                 map[string]string
+	Redirects               []Redirect
+	ContentType, Canonical  string
+	LastModified            time.Time
+	// Latencies holds the latency of every request of the url, including the
+	// retries.
+	Latencies []Latency
+	// RefExternalLinks holds the links of the page that were filtered out of
+	// the crawl, for example the links to other domains.
+	RefExternalLinks []string
+	// RefRejectedLinks holds the links of the page that can't be crawled
+	// because of their scheme, for example mailto: or javascript: links.
+	RefRejectedLinks []string
+	// RefNofollowLinks holds the links of the page that weren't followed
+	// because the page asked for them not to be.
+	RefNofollowLinks []string
+	// Generation is the checkpoint generation the url was claimed in, so the
+	// urls claimed after a checkpoint can be told apart on resume.
+	Generation int64
 }
 
 // NewMetric creates a new Metric
@@ -74,12 +143,23 @@ func NewMetric() *Metric {
 		Received:      NewClock(),
 		Filtered:      NewClock(),
 		Errorred:      NewClock(),
+		Skipped:       NewClock(),
+		Retried:       NewClock(),
 		Duration:      0,
 		RefLinks:      []string{},
 		RefAssetLinks: []string{},
+		RefKinds:      map[string]document.Kind{},
 	}
 }
 
+// SetDuration sets the duration of the request in a safe way
+func (m *Metric) SetDuration(d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Duration = d
+}
+
 // AppendRefLink adds a link to the metric in a safe way
 func (m *Metric) AppendRefLink(link string) {
 	m.mutex.Lock()
@@ -88,6 +168,33 @@ func (m *Metric) AppendRefLink(link string) {
 	m.RefLinks = append(m.RefLinks, link)
 }
 
+// AppendRefExternalLink adds a link that isn't crawled to the metric in a safe
+// way
+func (m *Metric) AppendRefExternalLink(link string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.RefExternalLinks = append(m.RefExternalLinks, link)
+}
+
+// AppendRefNofollowLink adds a link that isn't followed to the metric in a
+// safe way
+func (m *Metric) AppendRefNofollowLink(link string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.RefNofollowLinks = append(m.RefNofollowLinks, link)
+}
+
+// AppendRefRejectedLinks adds a series of links that can't be crawled to the
+// metric in a safe way
+func (m *Metric) AppendRefRejectedLinks(links []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.RefRejectedLinks = append(m.RefRejectedLinks, links...)
+}
+
 // AppendRefAssetLinks adds a series of assets links in a safe way
 func (m *Metric) AppendRefAssetLinks(assets []string) {
 	m.mutex.Lock()
@@ -96,6 +203,131 @@ func (m *Metric) AppendRefAssetLinks(assets []string) {
 	m.RefAssetLinks = append(m.RefAssetLinks, assets...)
 }
 
+// AppendLatency adds the latency of a request in a safe way
+func (m *Metric) AppendLatency(l Latency) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Latencies = append(m.Latencies, l)
+}
+
+// SetNoIndex marks the page as not to be indexed in a safe way
+func (m *Metric) SetNoIndex(noindex bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.NoIndex = noindex
+}
+
+// SetStatusCode sets the status code of the response in a safe way
+func (m *Metric) SetStatusCode(code int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.StatusCode = code
+}
+
+// SetContentLength sets the content length of the response in a safe way
+func (m *Metric) SetContentLength(length int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.ContentLength = length
+}
+
+// SetHeaders sets the recorded headers of the response in a safe way
+func (m *Metric) SetHeaders(headers map[string]string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Headers = headers
+}
+
+// SetRedirects sets the redirect chain of the request in a safe way
+func (m *Metric) SetRedirects(redirects []Redirect) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Redirects = redirects
+}
+
+// SetContentType sets the content type of the response in a safe way
+func (m *Metric) SetContentType(contentType string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.ContentType = contentType
+}
+
+// SetLastModified sets when the page was last modified in a safe way
+func (m *Metric) SetLastModified(t time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.LastModified = t
+}
+
+// SetCanonical sets the canonical url of the page in a safe way
+func (m *Metric) SetCanonical(canonical string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Canonical = canonical
+}
+
+// SetRefKind records the kind of a link or asset in a safe way
+func (m *Metric) SetRefKind(link string, kind document.Kind) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.RefKinds[link] = kind
+}
+
+// unclaimed returns true if the url was only ever skipped and never claimed,
+// so it can still be requested.
+func (m *Metric) unclaimed() bool {
+	return m.Requested.Time() == 0 && m.Skipped.Time() > 0
+}
+
+// Merge adds the counters of the other metric to the metric and takes the rest
+// of the values of the other metric, in a safe way. This allows a metric to be
+// built up away from the cache, without losing the changes made to the cached
+// metric in the meantime.
+func (m *Metric) Merge(o *Metric) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.Requested.Add(o.Requested.Time())
+	m.Received.Add(o.Received.Time())
+	m.Filtered.Add(o.Filtered.Time())
+	m.Errorred.Add(o.Errorred.Time())
+	m.Skipped.Add(o.Skipped.Time())
+	m.Retried.Add(o.Retried.Time())
+
+	m.Duration = o.Duration
+	m.Robots = o.Robots
+	m.RefLinks = o.RefLinks
+	m.RefAssetLinks = o.RefAssetLinks
+	m.RefExternalLinks = o.RefExternalLinks
+	m.RefRejectedLinks = o.RefRejectedLinks
+	m.RefNofollowLinks = o.RefNofollowLinks
+	for k, v := range o.RefKinds {
+		m.RefKinds[k] = v
+	}
+	m.NoIndex = o.NoIndex
+	m.StatusCode = o.StatusCode
+	m.ContentLength = o.ContentLength
+	m.Headers = o.Headers
+	m.Redirects = o.Redirects
+	m.ContentType = o.ContentType
+	m.Canonical = o.Canonical
+	m.LastModified = o.LastModified
+	m.Latencies = append(m.Latencies, o.Latencies...)
+}
+
 // Clock defines a metric for monitoring how many times something occurred.
 type Clock struct {
 	times int64
@@ -111,6 +343,11 @@ func (c *Clock) Increment() {
 	atomic.AddInt64(&c.times, 1)
 }
 
+// Add adds n to the clock timing
+func (c *Clock) Add(n int64) {
+	atomic.AddInt64(&c.times, n)
+}
+
 // Time returns how much movement the clock has changed.
 func (c *Clock) Time() int64 {
 	return atomic.LoadInt64(&c.times)
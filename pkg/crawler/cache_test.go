@@ -1,6 +1,8 @@
 package crawler
 
 import (
+	"reflect"
+	"sync"
 	"testing"
 	"testing/quick"
 
@@ -130,6 +132,80 @@ func TestClock(t *testing.T) {
 	})
 }
 
+// testCacheUpdate updates the same url from many goroutines at once, making
+// sure that none of the updates are lost.
+func testCacheUpdate(t *testing.T, cache Cache) {
+	const workers = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Update("http://a.com", func(m *Metric, _ bool) {
+				m.Filtered.Increment()
+			})
+		}()
+	}
+	wg.Wait()
+
+	m, err := cache.Get("http://a.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := int64(workers), m.Filtered.Time(); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestCacheUpdate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("concurrent", func(t *testing.T) {
+		testCacheUpdate(t, NewCache(log.NewNopLogger()))
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		cache := NewCache(log.NewNopLogger())
+
+		var exists []bool
+		for i := 0; i < 2; i++ {
+			cache.Update("http://a.com", func(m *Metric, ok bool) {
+				exists = append(exists, ok)
+			})
+		}
+		if expected, actual := []bool{false, true}, exists; !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+}
+
+func TestMetricMerge(t *testing.T) {
+	t.Parallel()
+
+	m := NewMetric()
+	m.Filtered.Increment()
+	m.Requested.Increment()
+
+	o := NewMetric()
+	o.Requested.Increment()
+	o.Received.Increment()
+	o.AppendRefLink("http://a.com/b")
+	o.SetStatusCode(200)
+
+	m.Merge(o)
+
+	if expected, actual := []int64{2, 1, 1}, []int64{m.Requested.Time(), m.Received.Time(), m.Filtered.Time()}; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []string{"http://a.com/b"}, m.RefLinks; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := 200, m.StatusCode; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
 func BenchmarkCacheExistsEmpty(b *testing.B) {
 	cache := NewCache(log.NewNopLogger())
 
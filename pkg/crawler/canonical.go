@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultTrackingParams are the query params that are commonly used for
+// tracking and sessions, which don't change the page that's served.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"gclid",
+	"fbclid",
+	"sid",
+	"phpsessid",
+	"jsessionid",
+}
+
+// Canonicalizer rewrites urls in to a canonical form, so that the different
+// ways of writing the url of a page are only crawled once.
+type Canonicalizer struct {
+	params []string
+}
+
+// NewCanonicalizer creates a Canonicalizer that strips the given query params.
+// A param ending in * matches any param with that prefix. Params are matched
+// case insensitively.
+func NewCanonicalizer(params []string) *Canonicalizer {
+	p := make([]string, len(params))
+	for k, v := range params {
+		p[k] = strings.ToLower(v)
+	}
+	return &Canonicalizer{
+		params: p,
+	}
+}
+
+// Canonicalize returns the canonical form of the url, the original url is left
+// untouched. The scheme and host are lowercased, default ports, fragments and
+// dot segments are removed and the query params are sorted without any of the
+// stripped params.
+// A nil Canonicalizer returns the url as is.
+func (c *Canonicalizer) Canonicalize(u *url.URL) *url.URL {
+	if c == nil || u.Opaque != "" {
+		return u
+	}
+
+	res := *u
+	res.Scheme = strings.ToLower(u.Scheme)
+	res.Host = canonicalHost(res.Scheme, u.Host)
+	res.Fragment = ""
+
+	if p := removeDotSegments(u.EscapedPath()); p != u.EscapedPath() {
+		if path, err := url.PathUnescape(p); err == nil {
+			res.Path, res.RawPath = path, p
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for k := range query {
+			if c.strip(k) {
+				query.Del(k)
+			}
+		}
+		// Encode sorts the params by key.
+		res.RawQuery = query.Encode()
+	}
+	res.ForceQuery = false
+
+	return &res
+}
+
+// strip returns if the query param should be removed.
+func (c *Canonicalizer) strip(param string) bool {
+	param = strings.ToLower(param)
+	for _, v := range c.params {
+		if strings.HasSuffix(v, "*") {
+			if strings.HasPrefix(param, v[:len(v)-1]) {
+				return true
+			}
+		} else if param == v {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalHost lowercases the host, removing the port if it's the default
+// port for the scheme.
+func canonicalHost(scheme, host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case scheme == "http" && strings.HasSuffix(host, ":80"):
+		return strings.TrimSuffix(host, ":80")
+	case scheme == "https" && strings.HasSuffix(host, ":443"):
+		return strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+// removeDotSegments removes the "." and ".." segments from a path, as defined
+// in RFC 3986 section 5.2.4.
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+
+	var (
+		segments = strings.Split(path, "/")
+		res      = make([]string, 0, len(segments))
+		last     = len(segments) - 1
+	)
+	for k, v := range segments {
+		switch v {
+		case ".":
+		case "..":
+			if n := len(res); n > 1 || (n == 1 && res[0] != "") {
+				res = res[:n-1]
+			}
+		default:
+			res = append(res, v)
+			continue
+		}
+		// A trailing dot segment still refers to a directory.
+		if k == last {
+			res = append(res, "")
+		}
+	}
+	return strings.Join(res, "/")
+}
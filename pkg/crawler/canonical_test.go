@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCanonicalizer(t *testing.T) {
+	t.Parallel()
+
+	c := NewCanonicalizer(DefaultTrackingParams)
+
+	for _, v := range []struct {
+		name, input, expected string
+	}{
+		{"unchanged", "http://a.com/b?c=d", "http://a.com/b?c=d"},
+		{"lowercase", "HTTP://A.Com/B", "http://a.com/B"},
+		{"http port", "http://a.com:80/b", "http://a.com/b"},
+		{"https port", "https://a.com:443/b", "https://a.com/b"},
+		{"other port", "http://a.com:443/b", "http://a.com:443/b"},
+		{"fragment", "http://a.com/b#c", "http://a.com/b"},
+		{"dot", "http://a.com/./b/.", "http://a.com/b/"},
+		{"dot dot", "http://a.com/b/../c/d/..", "http://a.com/c/"},
+		{"dot dot root", "http://a.com/../b", "http://a.com/b"},
+		{"escaped", "http://a.com/b%2Fc/../d", "http://a.com/d"},
+		{"sort query", "http://a.com/?d=1&c=2", "http://a.com/?c=2&d=1"},
+		{"tracking", "http://a.com/?utm_source=x&UTM_Medium=y&a=1", "http://a.com/?a=1"},
+		{"session", "http://a.com/?PHPSESSID=x", "http://a.com/"},
+		{"empty query", "http://a.com/?", "http://a.com/"},
+		{"opaque", "mailto:A@B.com", "mailto:A@B.com"},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			u, err := url.Parse(v.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			original := u.String()
+			if expected, actual := v.expected, c.Canonicalize(u).String(); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+			if expected, actual := original, u.String(); expected != actual {
+				t.Errorf("expected original to be untouched: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		var c *Canonicalizer
+
+		u, _ := url.Parse("HTTP://A.com:80/#b")
+		if expected, actual := u, c.Canonicalize(u); expected != actual {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/SimonRichardson/crwlr/pkg/report"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const defaultCheckTimeout = 10 * time.Second
+
+// Checker checks the links and assets of a crawl that weren't crawled, such
+// as the links to other domains and the images of a page, can be reached.
+type Checker struct {
+	client      *http.Client
+	agent       *peer.UserAgent
+	concurrency int
+	timeout     time.Duration
+	logger      log.Logger
+}
+
+// NewChecker creates a Checker from a http.Client, that requests at most
+// concurrency urls at any one time.
+func NewChecker(client *http.Client, agent *peer.UserAgent, concurrency int, logger log.Logger) *Checker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Checker{
+		client:      client,
+		agent:       agent,
+		concurrency: concurrency,
+		timeout:     defaultCheckTimeout,
+		logger:      logger,
+	}
+}
+
+// Check returns the report of the refs of every page with in a cache that are
+// broken. The outcome of the urls that were crawled is taken from their
+// metrics, every other ref is requested with a HEAD request, falling back to
+// a GET request for the hosts that don't support HEAD. This includes the
+// nofollow links and the urls that were skipped by the limits of the crawl.
+// A nil Checker doesn't request any urls, so only the refs that were crawled
+// are checked.
+func (c *Checker) Check(ctx context.Context, cache Cache) (*report.CheckReport, error) {
+	var (
+		refs   = map[string][]string{}
+		checks = map[string]report.Check{}
+		known  = map[string]struct{}{}
+	)
+	err := cache.Range(func(k string, v *Metric) bool {
+		m := NewStateMetric(v)
+		if m.Received > 0 && v.Robots == nil {
+			var r []string
+			r = append(r, m.RefLinks...)
+			r = append(r, m.RefAssetLinks...)
+			r = append(r, m.RefExternal...)
+			r = append(r, m.RefNofollow...)
+			refs[k] = r
+		}
+		if check, ok := metricCheck(m); ok {
+			checks[k] = check
+		}
+		// Urls that were skipped because of the limits of the crawl are
+		// still checked, but urls that were filtered are known, so robots.txt
+		// is still honoured.
+		if !v.unclaimed() {
+			known[k] = struct{}{}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c == nil {
+		return report.NewCheckReport(refs, checks), nil
+	}
+
+	var unchecked []string
+	for _, v := range refs {
+		for _, ref := range v {
+			if _, ok := known[ref]; !ok {
+				known[ref] = struct{}{}
+				unchecked = append(unchecked, ref)
+			}
+		}
+	}
+	sort.Strings(unchecked)
+
+	for k, v := range c.checkAll(ctx, unchecked) {
+		checks[k] = v
+	}
+	return report.NewCheckReport(refs, checks), nil
+}
+
+// checkAll requests every url, returning the outcome of each url.
+func (c *Checker) checkAll(ctx context.Context, urls []string) map[string]report.Check {
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+		queue = make(chan string)
+		res   = make(map[string]report.Check, len(urls))
+	)
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				check := c.check(ctx, u)
+
+				mutex.Lock()
+				res[u] = check
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, v := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- v
+	}
+	close(queue)
+	wg.Wait()
+
+	return res
+}
+
+// check requests the url with a HEAD request, falling back to a GET request if
+// the HEAD request fails, as not every host supports HEAD.
+func (c *Checker) check(ctx context.Context, u string) report.Check {
+	code, err := c.request(ctx, http.MethodHead, u)
+	if err == nil && code < 400 {
+		return report.Check{StatusCode: code}
+	}
+
+	level.Debug(c.logger).Log("url", u, "head", code, "err", err)
+
+	if code, err = c.request(ctx, http.MethodGet, u); err != nil {
+		if e, ok := err.(*url.Error); ok {
+			err = e.Err
+		}
+		return report.Check{Err: err.Error()}
+	}
+	return report.Check{StatusCode: code}
+}
+
+// request requests the url, without reading the body of the response.
+func (c *Checker) request(ctx context.Context, method, u string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", c.agent.Type(peer.Host))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// metricCheck returns the outcome of a url that was crawled, or false if the
+// url wasn't requested.
+func metricCheck(m StateMetric) (report.Check, bool) {
+	switch {
+	case m.Received > 0:
+		// The status code isn't known for crawls resumed from an older state.
+		if m.StatusCode == 0 {
+			return report.Check{StatusCode: http.StatusOK}, true
+		}
+		return report.Check{StatusCode: m.StatusCode}, true
+	case m.Errorred > 0:
+		check := report.Check{StatusCode: m.StatusCode}
+		switch {
+		case m.StatusCode >= 400:
+		case len(m.Redirects) > 0 && m.StatusCode == 0:
+			check.Err = "unable to follow redirects"
+		case m.StatusCode > 0:
+			check.Err = "unable to read response"
+		}
+		return check, true
+	}
+	return report.Check{}, false
+}
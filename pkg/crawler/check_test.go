@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/SimonRichardson/crwlr/pkg/report"
+	"github.com/go-kit/kit/log"
+)
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer external.Close()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, `<a href="/page">page</a><a href="/missing">missing</a>`+
+				`<a href="%[1]s/ok">ok</a><a href="%[1]s/nohead">nohead</a><a href="%[1]s/error">error</a>`+
+				`<img src="/missing.png">`, external.URL)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<a href="/missing">missing</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := peer.NewUserAgent("", "")
+	c := NewCrawler(http.DefaultClient, agent, false, false, 1, 1, log.NewNopLogger())
+	c.Filter(Addr(u))
+	c.Retry(RetryPolicy{MaxAttempts: 1})
+
+	if err := c.Run(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.CheckReport(context.Background(), NewChecker(http.DefaultClient, agent, 2, log.NewNopLogger()))
+
+	t.Run("checks", func(t *testing.T) {
+		for _, v := range []struct {
+			url        string
+			statusCode int
+		}{
+			{site.URL + "/page", http.StatusOK},
+			{site.URL + "/missing", http.StatusNotFound},
+			{site.URL + "/missing.png", http.StatusNotFound},
+			{external.URL + "/ok", http.StatusOK},
+			{external.URL + "/nohead", http.StatusOK},
+			{external.URL + "/error", http.StatusInternalServerError},
+		} {
+			check, ok := r.Check(v.url)
+			if !ok {
+				t.Errorf("%s expected: checked, actual: unchecked", v.url)
+				continue
+			}
+			if expected, actual := v.statusCode, check.StatusCode; expected != actual {
+				t.Errorf("%s expected: %d, actual: %d", v.url, expected, actual)
+			}
+		}
+	})
+
+	t.Run("crawled", func(t *testing.T) {
+		r := c.CheckReport(context.Background(), nil)
+
+		if _, ok := r.Check(external.URL + "/ok"); ok {
+			t.Errorf("expected: unchecked, actual: checked")
+		}
+		if expected, actual := []string{site.URL + "/missing"}, r.Refs(site.URL+"/page"); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("broken", func(t *testing.T) {
+		expected := []report.BrokenRef{
+			{URL: site.URL + "/missing", Check: report.Check{StatusCode: http.StatusNotFound}, Pages: []string{site.URL, site.URL + "/page"}},
+			{URL: site.URL + "/missing.png", Check: report.Check{StatusCode: http.StatusNotFound}, Pages: []string{site.URL}},
+			{URL: external.URL + "/error", Check: report.Check{StatusCode: http.StatusInternalServerError}, Pages: []string{site.URL}},
+		}
+		// The ports of the servers decide which sorts first.
+		if external.URL < site.URL {
+			expected = append(expected[2:], expected[:2]...)
+		}
+		if actual := r.Broken(); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+}
+
+func TestChecker_Uncrawled(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<a href="/page">page</a><a href="/private" rel="nofollow">private</a>`)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<a href="/deep">deep</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := peer.NewUserAgent("", "")
+	c := NewCrawler(http.DefaultClient, agent, false, false, 1, 1, log.NewNopLogger())
+	c.Filter(Addr(u))
+	c.Limit(Limits{MaxDepth: 1})
+
+	if err := c.Run(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.CheckReport(context.Background(), NewChecker(http.DefaultClient, agent, 1, log.NewNopLogger()))
+
+	expected := []report.BrokenRef{
+		{URL: site.URL + "/deep", Check: report.Check{StatusCode: http.StatusNotFound}, Pages: []string{site.URL + "/page"}},
+		{URL: site.URL + "/private", Check: report.Check{StatusCode: http.StatusNotFound}, Pages: []string{site.URL}},
+	}
+	if actual := r.Broken(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
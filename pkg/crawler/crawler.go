@@ -2,9 +2,13 @@ package crawler
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,7 +23,12 @@ import (
 	"golang.org/x/net/html"
 )
 
-const defaultRobotsTxt = "/robots.txt"
+const (
+	defaultRobotsTxt = "/robots.txt"
+
+	// defaultMaxDelay is the longest a host can back off the crawl for.
+	defaultMaxDelay = 30 * time.Second
+)
 
 var defaultRobotsURL, _ = url.Parse(defaultRobotsTxt)
 
@@ -28,25 +37,50 @@ type Crawler struct {
 	client           *http.Client
 	agent            *peer.UserAgent
 	filters          []Filter
-	stack            chan *url.URL
-	stop             chan chan struct{}
+	canonicalizer    *Canonicalizer
+	stack            *stack
+	pool             *Pool
+	budget           *budget
+	scheduler        *Scheduler
+	throttle         *Throttle
+	retry            RetryPolicy
+	maxDelay         time.Duration
+	mutex            sync.Mutex
+	cancel           context.CancelFunc
 	peers            sync.Pool
-	cache            *Cache
+	cache            Cache
+	robotsMutex      sync.Mutex
+	robotsCalls      map[string]*robotsCall
+	generation       int64
 	robotsRequest    bool
 	robotsCrawlDelay bool
-	done             bool
+	stylesheets      bool
+	ignoreNofollow   bool
+	headers          []string
+	sitemaps         []*url.URL
+	sitemapURLs      map[string]struct{}
+	seeds            []string
 	gauge            *Gauge
 	logger           log.Logger
 }
 
 // NewCrawler creates a Crawler from a http.Client
-func NewCrawler(client *http.Client, agent *peer.UserAgent, robotsRequest, robotsCrawlDelay bool, logger log.Logger) *Crawler {
+// The concurrency defines the maximum amount of requests in-flight at any one
+// time and concurrencyPerHost limits how many of those can be sent to the same
+// host.
+func NewCrawler(client *http.Client, agent *peer.UserAgent, robotsRequest, robotsCrawlDelay bool, concurrency, concurrencyPerHost int, logger log.Logger) *Crawler {
+	// Record the redirects of every request.
+	client = redirectClient(client)
+
 	return &Crawler{
-		client:  client,
-		agent:   agent,
-		stack:   make(chan *url.URL),
-		stop:    make(chan chan struct{}),
-		filters: []Filter{},
+		client:    client,
+		agent:     agent,
+		stack:     newStack(NewFIFO()),
+		pool:      NewPool(concurrency, concurrencyPerHost),
+		budget:    newBudget(Limits{}),
+		scheduler: NewScheduler(0),
+		maxDelay:  defaultMaxDelay,
+		filters:   []Filter{},
 		peers: sync.Pool{
 			New: func() interface{} {
 				return peer.NewAgent(client, agent, logger)
@@ -55,6 +89,8 @@ func NewCrawler(client *http.Client, agent *peer.UserAgent, robotsRequest, robot
 		cache:            NewCache(log.With(logger, "component", "cache")),
 		robotsRequest:    robotsRequest,
 		robotsCrawlDelay: robotsCrawlDelay,
+		sitemapURLs:      map[string]struct{}{},
+		robotsCalls:      map[string]*robotsCall{},
 		gauge:            NewGauge(),
 		logger:           logger,
 	}
@@ -67,105 +103,416 @@ func (c *Crawler) Filter(f Filter) {
 	c.filters = append(c.filters, f)
 }
 
-// Run executes the list of urls on the crawler stack
-func (c *Crawler) Run(u *url.URL) error {
-	// Increment the gauge when we add a url.
-	c.gauge.Increment()
-	go func() { c.stack <- u }()
+// Canonicalize defines how urls are rewritten in to a canonical form before
+// they're crawled, so the same page isn't crawled multiple times. By default
+// urls aren't rewritten.
+// Note: Canonicalize should be called before Run.
+func (c *Crawler) Canonicalize(canonicalizer *Canonicalizer) {
+	c.canonicalizer = canonicalizer
+}
 
-loop:
-	for {
-		select {
-		case v, ok := <-c.stack:
-			if !ok {
-				return errors.Errorf("unexpected stack url")
-			}
+// Frontier defines the strategy of the crawl, by changing the order in which
+// urls are crawled. By default the crawl is breadth-first.
+// Note: Frontier should be called before Run. Visiting order is only
+// deterministic with a concurrency of 1.
+func (c *Crawler) Frontier(f Frontier) {
+	c.stack = newStack(f)
+}
 
-			// Check to see if we're done or not.
-			if c.gauge.Value() < 1 {
-				c.done = true
-				break loop
-			}
+// Politeness defines the minimum delay between requests to the same host.
+// Note: Politeness should be called before Run.
+func (c *Crawler) Politeness(delay time.Duration) {
+	c.scheduler = NewScheduler(delay)
+}
 
-			if !c.filtered(v) {
-				continue
-			}
+// Throttle enables adaptive throttling, slowing down requests to hosts that
+// are struggling to respond. By default there is no throttling.
+// Note: Throttle should be called before Run.
+func (c *Crawler) Throttle(t *Throttle) {
+	c.throttle = t
+}
 
-			// Check to see if we need to request robots.txt
-			if c.robotsRequest {
-				// Get the robots for a giving host
-				group := c.getRobotsGroup(v)
-				if group.Test(v.Path) {
-					if c.robotsCrawlDelay && group.CrawlDelay > 0 {
-						time.Sleep(group.CrawlDelay)
-					}
-				} else {
-					// If the path is not allowed in the robots group, cache
-					// the path, so it will be bypassed if requested again.
-					go c.gauge.Decrement()
-					c.assignFilterMetric(v)
-				}
-			}
+// MaxDelay caps how long a host can back off the crawl for when it sends a
+// Retry-After header. By default it's 30 seconds.
+// Note: MaxDelay should be called before Run.
+func (c *Crawler) MaxDelay(d time.Duration) {
+	c.maxDelay = d
+}
 
-			// We can run this in a concurrent way now, as we've taken into
-			// consideration the crawl delay.
-			go c.fetch(v)
+// Retry defines how requests that fail for transient reasons are retried. By
+// default requests are never retried.
+// Note: Retry should be called before Run.
+func (c *Crawler) Retry(p RetryPolicy) {
+	c.retry = p
+}
 
-		case q := <-c.stop:
-			close(q)
-			break loop
-		}
+// Cache defines where the metrics of the crawl are stored. By default they're
+// held in memory.
+// Note: Cache should be called before Run.
+func (c *Crawler) Cache(cache Cache) {
+	c.cache = cache
+}
+
+// Limit defines the budget of the crawl, any url that is outside of the budget
+// will be skipped.
+// Note: Limit should be called before Run.
+func (c *Crawler) Limit(limits Limits) {
+	c.budget = newBudget(limits)
+}
+
+// Stylesheets enables parsing of stylesheets, so that the fonts, images and
+// imported stylesheets they reference are found. External stylesheets are
+// crawled along with the page that links to them, along with any <style>
+// blocks and style attributes of the page. By default stylesheets aren't
+// parsed.
+// Note: Stylesheets should be called before Run.
+func (c *Crawler) Stylesheets(enabled bool) {
+	c.stylesheets = enabled
+}
+
+// IgnoreNofollow crawls the links of a page that are marked as nofollow,
+// either with a rel="nofollow" or the robots directives of the page. By
+// default nofollow links aren't crawled.
+// Note: IgnoreNofollow should be called before Run.
+func (c *Crawler) IgnoreNofollow(ignore bool) {
+	c.ignoreNofollow = ignore
+}
+
+// Headers defines which response headers are recorded in the metric of each
+// url. By default no headers are recorded.
+// Note: Headers should be called before Run.
+func (c *Crawler) Headers(names []string) {
+	c.headers = names
+}
+
+// Run executes the list of seed urls on the crawler stack, using the worker
+// pool. Run only returns once all the urls have been crawled or the context is
+// done, at which point all the outstanding requests are aborted and the workers
+// drained before returning a CancelledError.
+func (c *Crawler) Run(ctx context.Context, seeds ...*url.URL) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.mutex.Lock()
+	c.cancel = cancel
+	c.mutex.Unlock()
+
+	// Once the context is done, no more urls will be handed out to the workers.
+	go func() {
+		<-ctx.Done()
+		c.stack.Close()
+	}()
+
+	c.budget.Start()
+
+	canonical := make([]*url.URL, len(seeds))
+	for k, u := range seeds {
+		canonical[k] = c.canonicalizer.Canonicalize(u)
+		c.push(Item{URL: canonical[k]})
+	}
+	c.mutex.Lock()
+	c.seeds = append(c.seeds, urlsToStrings(canonical)...)
+	c.mutex.Unlock()
+
+	c.readSitemaps(ctx, canonical)
+	if c.gauge.Value() < 1 {
+		return nil
 	}
 
+	c.pool.Run(func() {
+		c.work(ctx)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return &CancelledError{err}
+	}
 	return nil
 }
 
-// Close terminates any workers currently executing.
+// Close terminates any workers currently executing, aborting any requests that
+// are in-flight.
 func (c *Crawler) Close() {
-	if c.done {
-		return
+	c.stack.Close()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+func (c *Crawler) work(ctx context.Context) {
+	for {
+		// Only items for hosts with a free slot are handed out, so a worker is
+		// never blocked by a host that's saturated.
+		i, ok := c.stack.PopFor(c.pool.TryAcquire)
+		if !ok {
+			return
+		}
+
+		for _, v := range c.visit(ctx, i, true) {
+			c.push(v)
+		}
+
+		// If the visit was cancelled, it's left in-flight so that any
+		// checkpoint knows the visit never completed.
+		if ctx.Err() == nil {
+			c.stack.Done(i)
+		}
+
+		// The gauge tracks every url that's either waiting on the stack or
+		// being visited, so once it hits zero there is nothing left to do.
+		c.gauge.Decrement()
+		if c.gauge.Value() < 1 {
+			c.stack.Close()
+		}
+	}
+}
+
+// Visit crawls a single item, returning the metric of the item along with the
+// items that were discovered. Unlike Run, the discovered items aren't crawled,
+// which allows the caller to decide where they should be crawled. The caller
+// owns the item, so it's requested even if it has been visited before.
+func (c *Crawler) Visit(ctx context.Context, i Item) (*Metric, []Item) {
+	items := c.visit(ctx, i, false)
+	metric, err := c.cache.Get(i.URL.String())
+	if err != nil {
+		return nil, items
+	}
+	return metric, items
+}
+
+// visit crawls a single item. If acquired is true, the item was popped from the
+// stack along with the slot for the host of the item, otherwise the item is
+// owned by the caller of Visit, which decides what's crawled, and the slot is
+// acquired once the item is known to be requested.
+func (c *Crawler) visit(ctx context.Context, i Item, acquired bool) []Item {
+	u := i.URL
+	if acquired {
+		defer c.pool.Release(u.Host)
+	}
+
+	if !c.filtered(u) {
+		return nil
+	}
+
+	// Skip any urls that are too deep to be crawled.
+	if !c.budget.Depth(i.Depth) {
+		c.assignDepthSkipMetric(u)
+		return nil
+	}
+
+	// Check to see if we need to request robots.txt
+	if c.robotsRequest {
+		// Get the robots for a giving host
+		group := c.getRobotsGroup(ctx, u)
+		if !group.Test(u.Path) {
+			// If the path is not allowed in the robots group, cache the path,
+			// so it will be bypassed if requested again.
+			c.assignFilterMetric(u)
+			return nil
+		}
+		if c.robotsCrawlDelay && group.CrawlDelay > 0 {
+			c.scheduler.Delay(u.Host, group.CrawlDelay)
+		}
 	}
 
-	q := make(chan struct{})
-	c.stop <- q
-	<-q
+	// Claim the url before waiting on the host, so urls that have already been
+	// requested, or that are over budget, never wait on the host. Items owned
+	// by the caller of Visit aren't claimed, for example a url that's leased
+	// again once the result of an earlier lease expired has to be requested
+	// again to discover its links.
+	if acquired && !c.claim(u) {
+		return nil
+	}
+	if !acquired && !c.take(u) {
+		return nil
+	}
+
+	if !acquired {
+		c.pool.Acquire(u.Host)
+		defer c.pool.Release(u.Host)
+	}
+
+	// Wait for our turn to request the host, other hosts are free to be
+	// requested in the meantime by other workers.
+	if err := c.scheduler.Wait(ctx, u.Host); err != nil {
+		return nil
+	}
+
+	// The context could have been cancelled whilst waiting for the pool.
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return c.fetch(ctx, u, i.Depth)
+}
+
+func (c *Crawler) push(i Item) {
+	// Increment the gauge before the url is visible to the workers, so the
+	// gauge can never prematurely reach zero.
+	c.gauge.Increment()
+	if !c.stack.Push(i) {
+		c.gauge.Decrement()
+	}
 }
 
 // MetricsReport returns the report of all the metric things that have been
 // cached, missed or errorred.
 func (c *Crawler) MetricsReport(duration time.Duration) *report.MetricReport {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
+	r, err := NewMetricsReport(c.cache, duration)
+	if err != nil {
+		level.Warn(c.logger).Log("report", "metrics", "err", err)
+	}
+	return r
+}
+
+// SiteReport returns the report of all the sites pages whist crawling
+func (c *Crawler) SiteReport() *report.SiteReport {
+	r, err := NewSiteReport(c.cache)
+	if err != nil {
+		level.Warn(c.logger).Log("report", "site", "err", err)
+	}
+	return r
+}
+
+// RecordReport returns the report of every metric of every url of the crawl,
+// for encoding the crawl in to a machine-readable format.
+func (c *Crawler) RecordReport(duration time.Duration) *report.RecordReport {
+	r, err := NewRecordReport(c.cache, duration)
+	if err != nil {
+		level.Warn(c.logger).Log("report", "records", "err", err)
+	}
+	return r
+}
+
+// StatusReport returns the report of the status codes and redirects of the
+// crawl.
+func (c *Crawler) StatusReport(maxHops int) *report.StatusReport {
+	r, err := NewStatusReport(c.cache, maxHops)
+	if err != nil {
+		level.Warn(c.logger).Log("report", "status", "err", err)
+	}
+	return r
+}
+
+// CheckReport returns the report of the broken links and assets of the crawl,
+// using the checker to request the ones that weren't crawled. Without a
+// checker only the links and assets that were crawled are checked.
+func (c *Crawler) CheckReport(ctx context.Context, checker *Checker) *report.CheckReport {
+	r, err := checker.Check(ctx, c.cache)
+	if err != nil {
+		level.Warn(c.logger).Log("report", "check", "err", err)
+		return report.NewCheckReport(nil, nil)
+	}
+	return r
+}
 
+// NewMetricsReport returns the report of all the metrics with in a cache.
+func NewMetricsReport(cache Cache, duration time.Duration) (*report.MetricReport, error) {
 	// Take a snapshot of the cache metrics
-	m := map[string]*report.Row{}
-	for k, v := range c.cache.metrics {
+	var (
+		m       = map[string]*report.Row{}
+		inbound = map[string]int{}
+	)
+	err := cache.Range(func(k string, v *Metric) bool {
 		m[k] = &report.Row{
 			Requested: int(v.Requested.Time()),
 			Received:  int(v.Received.Time()),
 			Filtered:  int(v.Filtered.Time()),
 			Errorred:  int(v.Errorred.Time()),
+			Skipped:   int(v.Skipped.Time()),
+			Retried:   int(v.Retried.Time()),
 			Duration:  v.Duration,
+			Latencies: latenciesToReport(v.Latencies),
+		}
+		for _, link := range v.RefLinks {
+			inbound[link]++
 		}
+		return true
+	})
+	for k, v := range m {
+		v.Inbound = inbound[k]
 	}
 
-	return report.NewMetricReport(duration, m)
+	return report.NewMetricReport(duration, m), err
 }
 
-// SiteReport returns the report of all the sites pages whist crawling
-func (c *Crawler) SiteReport() *report.SiteReport {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
-
+// NewSiteReport returns the report of all the pages with in a cache.
+func NewSiteReport(cache Cache) (*report.SiteReport, error) {
 	p := map[string]*report.Page{}
-	for k, v := range c.cache.metrics {
+	err := cache.Range(func(k string, v *Metric) bool {
 		p[k] = &report.Page{
-			Links:  v.RefLinks,
-			Assets: v.RefAssetLinks,
+			Links:    v.RefLinks,
+			Assets:   v.RefAssetLinks,
+			Kinds:    kindsToStrings(v.RefKinds),
+			NoIndex:  v.NoIndex,
+			Duration: v.Duration,
+			Received: int(v.Received.Time()),
+			Errorred: int(v.Errorred.Time()),
 		}
-	}
+		return true
+	})
 
-	return report.NewSiteReport(p)
+	return report.NewSiteReport(p), err
+}
+
+// NewStatusReport returns the report of the status codes and redirects of the
+// urls with in a cache that were requested.
+func NewStatusReport(cache Cache, maxHops int) (*report.StatusReport, error) {
+	s := map[string]*report.Status{}
+	err := cache.Range(func(k string, v *Metric) bool {
+		m := NewStateMetric(v)
+		if m.StatusCode > 0 || m.Errorred > 0 || len(m.Redirects) > 0 {
+			s[k] = &report.Status{
+				StatusCode: m.StatusCode,
+				Redirects:  redirectsToReport(m.Redirects),
+			}
+		}
+		return true
+	})
+
+	return report.NewStatusReport(s, maxHops), err
+}
+
+// NewRecordReport returns the report of every metric with in a cache.
+func NewRecordReport(cache Cache, duration time.Duration) (*report.RecordReport, error) {
+	var records []report.Record
+	err := cache.Range(func(k string, v *Metric) bool {
+		m := NewStateMetric(v)
+		r := report.Record{
+			URL:              k,
+			StatusCode:       m.StatusCode,
+			ContentType:      m.ContentType,
+			ContentLength:    m.ContentLength,
+			Headers:          m.Headers,
+			Redirects:        redirectsToReport(m.Redirects),
+			Canonical:        m.Canonical,
+			NoIndex:          m.NoIndex,
+			Requested:        int(m.Requested),
+			Received:         int(m.Received),
+			Filtered:         int(m.Filtered),
+			Errorred:         int(m.Errorred),
+			Skipped:          int(m.Skipped),
+			Retried:          int(m.Retried),
+			Duration:         m.Duration,
+			Latencies:        latenciesToReport(m.Latencies),
+			RefLinks:         m.RefLinks,
+			RefAssets:        m.RefAssetLinks,
+			RefExternalLinks: m.RefExternal,
+			RefRejectedLinks: m.RefRejected,
+			RefNofollowLinks: m.RefNofollow,
+			RefKinds:         kindsToStrings(m.RefKinds),
+		}
+		if !m.LastModified.IsZero() {
+			r.LastModified = &m.LastModified
+		}
+		records = append(records, r)
+		return true
+	})
+
+	return report.NewRecordReport(duration, records), err
 }
 
 func (c *Crawler) filtered(u *url.URL) bool {
@@ -177,73 +524,214 @@ func (c *Crawler) filtered(u *url.URL) bool {
 	return true
 }
 
-func (c *Crawler) fetch(u *url.URL) {
-	str := u.String()
-	// We don't need to match existing ones
-	metric, err := c.cache.Get(str)
-	if err == nil {
-		metric.Requested.Increment()
-		go c.gauge.Decrement()
-		return
+// claim claims the url so no other worker requests it, returning false if the
+// url has already been claimed or there isn't enough budget left to request
+// it.
+func (c *Crawler) claim(u *url.URL) bool {
+	// We don't need to match existing ones, unless they were only skipped,
+	// for example when the url was first found too deep to be crawled.
+	var claimed bool
+	c.cache.Update(u.String(), func(m *Metric, ok bool) {
+		switch {
+		case !ok:
+			claimed = true
+		case m.unclaimed():
+			m.Skipped = NewClock()
+			claimed = true
+		default:
+			m.Requested.Increment()
+		}
+		if claimed {
+			m.Generation = atomic.LoadInt64(&c.generation)
+		}
+	})
+	if !claimed {
+		return false
 	}
-	// Make sure we do have a metric
-	if metric == nil {
-		metric = NewMetric()
-		c.cache.Set(str, metric)
+	return c.take(u)
+}
+
+// take takes the url from the budget, returning false if there isn't enough
+// budget left to request it.
+func (c *Crawler) take(u *url.URL) bool {
+	if !c.budget.Take() {
+		c.assignSkipMetric(u)
+		return false
 	}
+	return true
+}
+
+// fetch requests a url that has been claimed, returning the items that were
+// discovered.
+func (c *Crawler) fetch(ctx context.Context, u *url.URL, depth int) (children []Item) {
+	str := u.String()
+	// Store the metric once the fetch is complete, merging it with any changes
+	// made to the cached metric in the meantime, for example by other pages
+	// linking to the url.
+	metric := NewMetric()
+	defer c.cache.Update(str, func(m *Metric, _ bool) {
+		m.Merge(metric)
+	})
 
 	began := time.Now()
 	metric.Requested.Increment()
 
 	level.Debug(c.logger).Log("url", str)
 
-	body, err := c.request(u, peer.Host, checkResponseStatus)
+	body, header, err := c.retryRequest(ctx, u, metric)
 	if err != nil {
 		metric.Errorred.Increment()
-		return
+		return nil
 	}
+	c.budget.Consume(len(body))
 
-	links, assets, err := c.collect(body, u)
+	p, err := c.collect(body, header, u)
 	if err != nil {
 		metric.Errorred.Increment()
-		return
+		return nil
 	}
 
 	metric.Received.Increment()
-	metric.Duration = time.Since(began)
+	metric.SetDuration(time.Since(began))
+	metric.SetNoIndex(p.directives.NoIndex)
+	// The content length isn't known when the body is compressed or chunked.
+	if metric.ContentLength < 0 {
+		metric.SetContentLength(int64(len(body)))
+	}
+	if t, err := http.ParseTime(header.Get("Last-Modified")); err == nil {
+		metric.SetLastModified(t)
+	}
+	if p.canonical != nil {
+		metric.SetCanonical(c.canonicalizer.Canonicalize(p.canonical).String())
+	}
+
+	assets := make([]*url.URL, len(p.assets))
+	for k, v := range p.assets {
+		assets[k] = c.canonicalizer.Canonicalize(v)
+		metric.SetRefKind(assets[k].String(), p.kinds[v])
+
+		// Stylesheets are crawled at the same depth as the page, as they're
+		// part of the page.
+		if c.stylesheets && p.kinds[v] == document.KindStylesheet {
+			if u := assets[k]; c.filtered(u) && !c.claimed(u.String()) {
+				children = append(children, Item{URL: u, Depth: depth})
+			}
+		}
+	}
 	metric.AppendRefAssetLinks(urlsToStrings(assets))
+	metric.AppendRefRejectedLinks(urlsToStrings(p.rejected))
+
+	for _, v := range p.links {
+		u := c.canonicalizer.Canonicalize(v)
 
-	for _, u := range links {
 		// Preemptively remove any links that we know are invalid
-		// or essentially a no-op.
+		// or essentially a no-op, recording them so they can still be
+		// checked.
 		if !c.filtered(u) {
+			metric.AppendRefExternalLink(u.String())
 			continue
 		}
 
+		// The page asked for the link not to be followed, but it's recorded
+		// so it can still be checked.
+		if !c.ignoreNofollow && p.nofollow(v) {
+			level.Debug(c.logger).Log("url", str, "nofollow", u.String())
+			metric.AppendRefNofollowLink(u.String())
+			continue
+		}
+
+		// Record every link of the page, even the ones that have already been
+		// crawled, so it's known which pages can be reached by links.
 		str := u.String()
-		if c.cache.Exists(str) {
+		metric.AppendRefLink(str)
+		metric.SetRefKind(str, p.kinds[v])
+
+		if c.claimed(str) {
 			c.assignFilterMetric(u)
 			continue
 		}
 
-		metric.AppendRefLink(str)
+		// Links that are too deep never enter the frontier.
+		if !c.budget.Depth(depth + 1) {
+			c.assignDepthSkipMetric(u)
+			continue
+		}
 
-		// Increment the gauge
-		c.gauge.Increment()
-		go func(u *url.URL) { c.stack <- u }(u)
+		children = append(children, Item{URL: u, Depth: depth + 1})
 	}
+	return children
+}
+
+// retryRequest requests a url, retrying any transient failures as defined by
+// the retry policy. Every retry is recorded on the metric.
+func (c *Crawler) retryRequest(ctx context.Context, u *url.URL, metric *Metric) (body []byte, header http.Header, err error) {
+	for attempt := 1; ; attempt++ {
+		var (
+			trace = newLatencyTrace()
+			chain = &redirectChain{}
+		)
+		body, err = c.request(chain.WithContext(trace.WithContext(ctx)), u, peer.Host, func(resp *http.Response) error {
+			header = resp.Header
+			metric.SetStatusCode(resp.StatusCode)
+			metric.SetContentType(resp.Header.Get("Content-Type"))
+			metric.SetContentLength(resp.ContentLength)
+			metric.SetHeaders(selectHeaders(resp.Header, c.headers))
+			return checkResponseStatus(resp)
+		})
+		latency := trace.Done(len(body))
+		metric.AppendLatency(latency)
+		metric.SetRedirects(chain.Hops())
+		c.observe(ctx, u, latency.Total, err)
+
+		if ctx.Err() != nil || !c.retry.Retry(attempt, err) {
+			return
+		}
+
+		metric.Retried.Increment()
+		level.Debug(c.logger).Log("url", u.String(), "attempt", attempt, "err", err)
 
-	go c.gauge.Decrement()
+		timer := time.NewTimer(c.retry.Backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		// Make sure we're still polite to the host, this includes honouring
+		// any Retry-After the host has sent.
+		if err = c.scheduler.Wait(ctx, u.Host); err != nil {
+			return
+		}
+	}
 }
 
-func (c *Crawler) getRobotsGroup(u *url.URL) *robotstxt.Group {
-	// Get the robot.txt from the domain.
-	robotsURL := u.ResolveReference(defaultRobotsURL)
-	metric, err := c.cache.Get(robotsURL.String())
-	if err != nil {
-		metric = c.requestRobots(robotsURL)
+// observe feeds the outcome of a request back into the scheduler, so the host
+// can be backed off or slowed down if it's struggling.
+func (c *Crawler) observe(ctx context.Context, u *url.URL, latency time.Duration, err error) {
+	// Cancelling isn't the fault of the host.
+	if ctx.Err() != nil {
+		return
 	}
 
+	if e, ok := errors.Cause(err).(*StatusError); ok && e.RetryAfter > 0 {
+		delay := e.RetryAfter
+		if delay > c.maxDelay {
+			delay = c.maxDelay
+		}
+		level.Debug(c.logger).Log("url", u.String(), "retry_after", e.RetryAfter, "delay", delay)
+		c.scheduler.Backoff(u.Host, time.Now().Add(delay))
+	}
+
+	if c.throttle != nil {
+		delay := c.throttle.Observe(u.Host, latency, overloaded(err))
+		c.scheduler.Throttle(u.Host, delay)
+	}
+}
+
+func (c *Crawler) getRobotsGroup(ctx context.Context, u *url.URL) *robotstxt.Group {
+	metric := c.getRobots(ctx, u)
+
 	// Empty group
 	group := &robotstxt.Group{}
 	if metric.Robots != nil {
@@ -252,17 +740,66 @@ func (c *Crawler) getRobotsGroup(u *url.URL) *robotstxt.Group {
 	return group
 }
 
+// getRobots returns the metric of the robots.txt for the host of the url,
+// requesting the robots.txt if it hasn't been requested yet.
+func (c *Crawler) getRobots(ctx context.Context, u *url.URL) *Metric {
+	// Get the robot.txt from the domain.
+	robotsURL := u.ResolveReference(defaultRobotsURL)
+	key := robotsURL.String()
+	if metric, err := c.cache.Get(key); err == nil {
+		return metric
+	}
+
+	// Prevent multiple workers requesting the same robots.txt at once, without
+	// holding up the workers requesting other hosts.
+	c.robotsMutex.Lock()
+	if call, ok := c.robotsCalls[key]; ok {
+		c.robotsMutex.Unlock()
+		<-call.done
+		return call.metric
+	}
+	// The robots.txt could have been cached whilst waiting for the lock.
+	if metric, err := c.cache.Get(key); err == nil {
+		c.robotsMutex.Unlock()
+		return metric
+	}
+	call := &robotsCall{done: make(chan struct{})}
+	c.robotsCalls[key] = call
+	c.robotsMutex.Unlock()
+
+	call.metric = c.requestRobots(ctx, robotsURL)
+
+	c.robotsMutex.Lock()
+	delete(c.robotsCalls, key)
+	c.robotsMutex.Unlock()
+	close(call.done)
+
+	return call.metric
+}
+
+// robotsCall is a request for a robots.txt that's in-flight, which other
+// workers can wait on for the same host.
+type robotsCall struct {
+	done   chan struct{}
+	metric *Metric
+}
+
 // Request a document and read it's response body
-func (c *Crawler) request(u *url.URL, agentType peer.AgentType, fn func(*http.Response) error) (body []byte, err error) {
+func (c *Crawler) request(ctx context.Context, u *url.URL, agentType peer.AgentType, fn func(*http.Response) error) (body []byte, err error) {
 	agent := c.peers.Get().(*peer.Agent)
 	defer c.peers.Put(agent)
 
+	// The agent context has to out live the reading of the body.
+	agentCtx := peer.NewAgentContext(ctx, u)
+	defer agentCtx.Cancel()
+
 	var resp *http.Response
-	if resp, err = agent.Request(peer.NewAgentContext(u), agentType); err != nil {
+	if resp, err = agent.Request(agentCtx, agentType); err != nil {
 		return
 	}
 
 	if err = fn(resp); err != nil {
+		resp.Body.Close()
 		return
 	}
 
@@ -279,19 +816,68 @@ func (c *Crawler) request(u *url.URL, agentType peer.AgentType, fn func(*http.Re
 
 func checkResponseStatus(resp *http.Response) error {
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return errors.Errorf("bad status code: %s", resp.Status)
+		return newStatusError(resp)
 	}
 	return nil
 }
 
 func checkRobotsResponseStatus(resp *http.Response) error {
 	if resp.StatusCode >= 500 && resp.StatusCode < 600 {
-		return errors.Errorf("bad status code: %s", resp.Status)
+		return newStatusError(resp)
 	}
 	return nil
 }
 
-func (c *Crawler) requestRobots(u *url.URL) *Metric {
+// StatusError is returned when a response has a bad status code.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter is how long the host asked to wait before the next request,
+	// taken from the Retry-After header. Zero if not present.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return "bad status code: " + e.Status
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	err := &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+	// Retry-After is only meaningful when the host is asking us to back off.
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		err.RetryAfter = retryAfter(resp, time.Now())
+	}
+	return err
+}
+
+// retryAfter parses the Retry-After header, which can either be in seconds or
+// a http date.
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (c *Crawler) requestRobots(ctx context.Context, u *url.URL) *Metric {
 	var (
 		err        error
 		body       []byte
@@ -301,7 +887,7 @@ func (c *Crawler) requestRobots(u *url.URL) *Metric {
 		metric = NewMetric()
 	)
 
-	body, err = c.request(u, peer.Robot, func(resp *http.Response) error {
+	body, err = c.request(ctx, u, peer.Robot, func(resp *http.Response) error {
 		statusCode = resp.StatusCode
 		return checkRobotsResponseStatus(resp)
 	})
@@ -319,43 +905,160 @@ func (c *Crawler) requestRobots(u *url.URL) *Metric {
 		metric.Errorred.Increment()
 	}
 	metric.Requested.Increment()
-	metric.Duration = time.Since(began)
+	metric.SetDuration(time.Since(began))
 
+	// A cancelled request says nothing about the robots.txt, so it's not
+	// cached, otherwise the host would be allowed everything.
+	if ctx.Err() != nil {
+		return metric
+	}
 	c.cache.Set(u.String(), metric)
 
 	return metric
 }
 
 // Collect all the links with in a document
-func (c *Crawler) collect(body []byte, u *url.URL) (links []*url.URL, assets []*url.URL, err error) {
+func (c *Crawler) collect(body []byte, header http.Header, u *url.URL) (p *page, err error) {
+	p = &page{
+		kinds:      map[*url.URL]document.Kind{},
+		nofollows:  map[string]struct{}{},
+		directives: document.RobotsTag(c.agent.Robot, header["X-Robots-Tag"]),
+	}
+
+	refs := func(url *url.URL, kind document.Kind) error {
+		switch {
+		case kind.Link():
+			p.links = append(p.links, url)
+		case kind.Asset():
+			p.assets = append(p.assets, url)
+		case kind == document.KindCanonical:
+			if p.canonical == nil {
+				p.canonical = url
+			}
+			return nil
+		default:
+			return nil
+		}
+		p.kinds[url] = kind
+		return nil
+	}
+
+	if isStylesheet(header.Get("Content-Type")) {
+		err = document.NewStylesheet(u, body).Walk(refs)
+		return
+	}
+
 	var node *html.Node
 	if node, err = html.Parse(bytes.NewBuffer(body)); err != nil {
 		return
 	}
 
+	walker := document.Compose(
+		document.Compose(
+			document.Refs(refs),
+			document.Rejected(func(url *url.URL) error {
+				level.Debug(c.logger).Log("url", u.String(), "rejected", url.String())
+				p.rejected = append(p.rejected, url)
+				return nil
+			}),
+		),
+		document.Compose(
+			document.MetaRobots(c.agent.Robot, func(d document.Directives) error {
+				p.directives = p.directives.Merge(d)
+				return nil
+			}),
+			document.NoFollow(func(url *url.URL) error {
+				p.nofollows[url.String()] = struct{}{}
+				return nil
+			}),
+		),
+	)
+	if c.stylesheets {
+		walker = document.Compose(walker, document.Styles(refs))
+	}
+
 	doc := document.NewDocument(u, node, log.With(c.logger, "component", "document"))
-	err = doc.Walk(document.Compose(
-		document.Links(func(url *url.URL) error {
-			links = append(links, url)
-			return nil
-		}),
-		document.Assets(func(url *url.URL) error {
-			assets = append(assets, url)
-			return nil
-		}),
-	))
+	err = doc.Walk(walker)
 	return
 }
 
+// page holds everything that was collected from a page.
+type page struct {
+	links, assets []*url.URL
+	rejected      []*url.URL
+	kinds         map[*url.URL]document.Kind
+	nofollows     map[string]struct{}
+	directives    document.Directives
+	canonical     *url.URL
+}
+
+// nofollow returns if the link of the page shouldn't be followed.
+func (p *page) nofollow(u *url.URL) bool {
+	if p.directives.NoFollow {
+		return true
+	}
+	_, ok := p.nofollows[u.String()]
+	return ok
+}
+
+// isStylesheet returns if the content type is that of a css stylesheet.
+func isStylesheet(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/css"
+}
+
 // Assign the filtered mertic to the disallowed
 func (c *Crawler) assignFilterMetric(u *url.URL) {
-	s := u.String()
-	metric, err := c.cache.Get(s)
-	if err != nil {
-		metric = NewMetric()
-		c.cache.Set(s, metric)
-	}
-	metric.Filtered.Increment()
+	c.cache.Update(u.String(), func(m *Metric, _ bool) {
+		m.Filtered.Increment()
+	})
+}
+
+// CancelledError is returned when a crawl is cancelled before it could
+// complete.
+type CancelledError struct {
+	Err error
+}
+
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("crawl cancelled: %v", e.Err)
+}
+
+// Cause returns the underlying reason for the cancellation.
+func (e *CancelledError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying reason for the cancellation.
+func (e *CancelledError) Unwrap() error {
+	return e.Err
+}
+
+// Assign the skipped metric to urls that are outside of the budget
+func (c *Crawler) assignSkipMetric(u *url.URL) {
+	c.cache.Update(u.String(), func(m *Metric, _ bool) {
+		m.Skipped.Increment()
+	})
+}
+
+// Assign the skipped metric to urls that are too deep to be crawled, unless
+// the url is already cached, in which case it's filtered instead. Either way
+// the url can still be claimed if it's found again at a shallower depth.
+func (c *Crawler) assignDepthSkipMetric(u *url.URL) {
+	c.cache.Update(u.String(), func(m *Metric, ok bool) {
+		if ok {
+			m.Filtered.Increment()
+			return
+		}
+		m.Skipped.Increment()
+	})
+}
+
+// claimed returns true if the url has already been claimed, rather than only
+// skipped.
+func (c *Crawler) claimed(u string) bool {
+	m, err := c.cache.Get(u)
+	return err == nil && !m.unclaimed()
 }
 
 // Gauge defines a value that can go both up and down in a safe way.
@@ -383,6 +1086,33 @@ func (c *Gauge) Value() int64 {
 	return atomic.LoadInt64(&c.value)
 }
 
+func kindsToStrings(a map[string]document.Kind) map[string]string {
+	res := make(map[string]string, len(a))
+	for k, v := range a {
+		res[k] = string(v)
+	}
+	return res
+}
+
+func redirectsToReport(a []Redirect) []report.Redirect {
+	if len(a) == 0 {
+		return nil
+	}
+	res := make([]report.Redirect, len(a))
+	for k, v := range a {
+		res[k] = report.Redirect(v)
+	}
+	return res
+}
+
+func latenciesToReport(a []Latency) []report.Latency {
+	res := make([]report.Latency, len(a))
+	for k, v := range a {
+		res[k] = report.Latency(v)
+	}
+	return res
+}
+
 func urlsToStrings(a []*url.URL) []string {
 	res := make([]string, len(a))
 	for k, v := range a {
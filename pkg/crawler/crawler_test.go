@@ -1,14 +1,19 @@
 package crawler
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/quick"
+	"time"
 
+	"github.com/SimonRichardson/crwlr/pkg/document"
 	"github.com/SimonRichardson/crwlr/pkg/peer"
 	"github.com/SimonRichardson/crwlr/pkg/static"
 	"github.com/SimonRichardson/crwlr/pkg/test"
@@ -30,24 +35,29 @@ func TestCrawl_Collect(t *testing.T) {
 		fn := func(a test.ASCII) bool {
 			body := fmt.Sprintf(`<a href="/%s">%s</a>`, a.String(), a.String())
 
-			c := NewCrawler(client, agent, false, false, logger)
-			links, assets, err := c.collect([]byte(body), u)
+			c := NewCrawler(client, agent, false, false, 1, 1, logger)
+			p, err := c.collect([]byte(body), http.Header{}, u)
 			if err != nil {
 				t.Error(err)
 				return false
 			}
 
-			if expected, actual := 1, len(links); expected != actual {
+			if expected, actual := 1, len(p.links); expected != actual {
 				t.Errorf("expected: %d, actual: %d", expected, actual)
 				return false
 			}
 
-			if expected, actual := 0, len(assets); expected != actual {
+			if expected, actual := 0, len(p.assets); expected != actual {
 				t.Errorf("expected: %d, actual: %d", expected, actual)
 				return false
 			}
 
-			if expected, actual := fmt.Sprintf("%s/%s", u.String(), a.String()), links[0].String(); expected != actual {
+			if expected, actual := fmt.Sprintf("%s/%s", u.String(), a.String()), p.links[0].String(); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+				return false
+			}
+
+			if expected, actual := document.KindAnchor, p.kinds[p.links[0]]; expected != actual {
 				t.Errorf("expected: %s, actual: %s", expected, actual)
 				return false
 			}
@@ -80,8 +90,8 @@ func TestCrawl_Request(t *testing.T) {
 	}
 
 	t.Run("fetch cache", func(t *testing.T) {
-		c := NewCrawler(client, agent, false, false, logger)
-		c.fetch(u)
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.fetch(context.Background(), u, 0)
 
 		if !c.cache.Exists(u.String()) {
 			t.Error("expected to have populated the cache")
@@ -98,8 +108,8 @@ func TestCrawl_Request(t *testing.T) {
 
 			base := u.ResolveReference(path)
 
-			c := NewCrawler(client, agent, false, false, logger)
-			c.fetch(base)
+			c := NewCrawler(client, agent, false, false, 1, 1, logger)
+			c.fetch(context.Background(), base, 0)
 
 			if m, err := c.cache.Get(base.String()); err == nil {
 				if actual := m.Requested.Time(); actual != 1 {
@@ -119,6 +129,125 @@ func TestCrawl_Request(t *testing.T) {
 	})
 }
 
+func TestCrawl_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	// Setup
+	var (
+		client = http.DefaultClient
+		agent  = peer.NewUserAgent("", "")
+		logger = log.NewNopLogger()
+		server = httptest.NewServer(mux)
+	)
+	defer server.Close()
+
+	// Make sure we've got a valid url
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("backoff", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.fetch(context.Background(), u, 0)
+
+		if next := time.Until(c.scheduler.Next(u.Host)); next < time.Second*15 || next > defaultMaxDelay {
+			t.Errorf("expected: ~%s, actual: %s", defaultMaxDelay, next)
+		}
+
+		m, err := c.cache.Get(u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(1), m.Errorred.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("max delay", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.MaxDelay(time.Second)
+		c.fetch(context.Background(), u, 0)
+
+		if next := time.Until(c.scheduler.Next(u.Host)); next > time.Second {
+			t.Errorf("expected: <=1s, actual: %s", next)
+		}
+	})
+
+	t.Run("throttle", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.Throttle(NewThrottle(time.Second, 0.1, time.Minute))
+		c.fetch(context.Background(), u, 0)
+
+		c.scheduler.mutex.Lock()
+		delay := c.scheduler.host(u.Host).throttle
+		c.scheduler.mutex.Unlock()
+
+		if delay == 0 {
+			t.Error("expected host to be throttled")
+		}
+	})
+}
+
+func TestCrawl_Retry(t *testing.T) {
+	t.Parallel()
+
+	var requests int64
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("<html></html>"))
+	})
+
+	// Setup
+	var (
+		client = http.DefaultClient
+		agent  = peer.NewUserAgent("", "")
+		logger = log.NewNopLogger()
+		server = httptest.NewServer(mux)
+	)
+	defer server.Close()
+
+	// Make sure we've got a valid url
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("retried", func(t *testing.T) {
+		policy := DefaultRetryPolicy()
+		policy.BaseBackoff = time.Millisecond
+
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.Retry(policy)
+		c.fetch(context.Background(), u, 0)
+
+		m, err := c.cache.Get(u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(2), m.Retried.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := int64(1), m.Received.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := int64(0), m.Errorred.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+}
+
 func TestCrawl_RequestRobots(t *testing.T) {
 	t.Parallel()
 
@@ -138,8 +267,8 @@ func TestCrawl_RequestRobots(t *testing.T) {
 	}
 
 	t.Run("fetch cache", func(t *testing.T) {
-		c := NewCrawler(client, agent, false, false, logger)
-		c.requestRobots(u)
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.requestRobots(context.Background(), u)
 
 		if !c.cache.Exists(u.String()) {
 			t.Error("expected to have populated the cache")
@@ -147,8 +276,8 @@ func TestCrawl_RequestRobots(t *testing.T) {
 	})
 
 	t.Run("fetch metric", func(t *testing.T) {
-		c := NewCrawler(client, agent, false, false, logger)
-		c.requestRobots(u)
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.requestRobots(context.Background(), u)
 
 		if m, err := c.cache.Get(u.String()); err == nil {
 			if actual := m.Requested.Time(); actual != 1 {
@@ -166,6 +295,18 @@ func TestCrawl_RequestRobots(t *testing.T) {
 
 		t.Error("expected to have a set of metrics")
 	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.requestRobots(ctx, u)
+
+		if c.cache.Exists(u.String()) {
+			t.Error("expected not to have populated the cache")
+		}
+	})
 }
 
 func TestCrawl_GetRobotsGroup(t *testing.T) {
@@ -187,13 +328,60 @@ func TestCrawl_GetRobotsGroup(t *testing.T) {
 	}
 
 	t.Run("get", func(t *testing.T) {
-		c := NewCrawler(client, agent, false, false, logger)
-		group := c.getRobotsGroup(u)
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		group := c.getRobotsGroup(context.Background(), u)
 
 		if group == nil {
 			t.Error("expected to have a group")
 		}
 	})
+
+	t.Run("per host", func(t *testing.T) {
+		var (
+			requests int64
+			block    = make(chan struct{})
+		)
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(&requests, 1)
+			<-block
+		}))
+		defer slow.Close()
+
+		s, err := url.Parse(slow.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.getRobots(context.Background(), s)
+			}()
+		}
+
+		// A slow robots.txt doesn't hold up the robots.txt of other hosts.
+		done := make(chan struct{})
+		go func() {
+			c.getRobots(context.Background(), u)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Error("expected other hosts not to be blocked")
+		}
+
+		close(block)
+		wg.Wait()
+
+		if expected, actual := int64(1), atomic.LoadInt64(&requests); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
 }
 
 func TestCrawl_AssignFilterMetric(t *testing.T) {
@@ -217,7 +405,7 @@ func TestCrawl_AssignFilterMetric(t *testing.T) {
 
 			base := u.ResolveReference(path)
 
-			c := NewCrawler(client, agent, false, false, logger)
+			c := NewCrawler(client, agent, false, false, 1, 1, logger)
 
 			amount := int(b % 1000)
 			for i := 0; i < amount; i++ {
@@ -243,6 +431,287 @@ func TestCrawl_AssignFilterMetric(t *testing.T) {
 	})
 }
 
+func TestCrawl_Run(t *testing.T) {
+	t.Parallel()
+
+	// Setup
+	var (
+		client = http.DefaultClient
+		agent  = peer.NewUserAgent("", "")
+		logger = log.NewNopLogger()
+		server = httptest.NewServer(static.NewAPI(false, logger))
+	)
+	defer server.Close()
+
+	// Make sure we've got a valid url
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("terminates", func(t *testing.T) {
+		c := NewCrawler(client, agent, true, false, 4, 2, logger)
+		c.Filter(Addr(u))
+
+		done := make(chan error)
+		go func() { done <- c.Run(context.Background(), u) }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected crawl to terminate")
+		}
+
+		if expected, actual := int64(0), c.gauge.Value(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if !c.cache.Exists(fmt.Sprintf("%s/page2", u.String())) {
+			t.Error("expected to have crawled page2")
+		}
+	})
+
+	t.Run("canonical addr", func(t *testing.T) {
+		seed, err := url.Parse(fmt.Sprintf("http://LOCALHOST:%s", u.Port()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := NewCrawler(client, agent, false, false, 4, 2, logger)
+		c.Filter(Addr(seed))
+		c.Canonicalize(NewCanonicalizer(DefaultTrackingParams))
+
+		if err := c.Run(context.Background(), seed); err != nil {
+			t.Fatal(err)
+		}
+		if !c.cache.Exists(fmt.Sprintf("http://localhost:%s/page2", u.Port())) {
+			t.Error("expected to have crawled page2")
+		}
+	})
+
+	t.Run("canonicalize", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 4, 2, logger)
+		c.Filter(Addr(u))
+		c.Canonicalize(NewCanonicalizer(DefaultTrackingParams))
+
+		seed, err := url.Parse(fmt.Sprintf("%s/./index?utm_source=a#b", u.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Run(context.Background(), seed); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := c.cache.Get(fmt.Sprintf("%s/index", u.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(1), m.Received.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if c.cache.Exists(seed.String()) {
+			t.Error("expected seed to be canonicalized")
+		}
+	})
+
+	t.Run("disk cache", func(t *testing.T) {
+		cache, _, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		c := NewCrawler(client, agent, true, false, 4, 2, logger)
+		c.Filter(Addr(u))
+		c.Cache(cache)
+
+		if err := c.Run(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := cache.Get(u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(1), m.Received.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if len(m.RefLinks) == 0 {
+			t.Error("expected links to have been stored")
+		}
+		if !cache.Exists(fmt.Sprintf("%s/page2", u.String())) {
+			t.Error("expected to have crawled page2")
+		}
+	})
+
+	t.Run("max pages", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 4, 2, logger)
+		c.Filter(Addr(u))
+		c.Limit(Limits{MaxPages: 1})
+
+		if err := c.Run(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+
+		var received, skipped int64
+		c.cache.Range(func(_ string, m *Metric) bool {
+			received += m.Received.Time()
+			skipped += m.Skipped.Time()
+			return true
+		})
+
+		if expected, actual := int64(1), received; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if skipped < 1 {
+			t.Errorf("expected: > 0, actual: %d", skipped)
+		}
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 4, 2, logger)
+		c.Filter(Addr(u))
+		c.Limit(Limits{MaxDepth: 1})
+
+		if err := c.Run(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := c.cache.Get(fmt.Sprintf("%s/page2", u.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected, actual := int64(0), m.Received.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := int64(1), m.Skipped.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("shallower", func(t *testing.T) {
+		site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				fmt.Fprint(w, `<a href="/b">b</a><a href="/c">c</a>`)
+			case "/c":
+				fmt.Fprint(w, `<a href="/d">d</a>`)
+			case "/d":
+				fmt.Fprint(w, `<a href="/b">b</a>`)
+			}
+		}))
+		defer site.Close()
+
+		u, err := url.Parse(site.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Depth-first, /b is found too deep through /d before it's popped at
+		// its shallower depth.
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.Filter(Addr(u))
+		c.Frontier(NewLIFO())
+		c.Limit(Limits{MaxDepth: 2})
+
+		if err := c.Run(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := c.cache.Get(fmt.Sprintf("%s/b", u.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(1), m.Received.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := int64(0), m.Skipped.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 4, 2, logger)
+		c.Filter(Addr(u))
+		c.Close()
+
+		done := make(chan error)
+		go func() { done <- c.Run(context.Background(), u) }()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected crawl to terminate")
+		}
+	})
+}
+
+func TestCrawl_RunCancel(t *testing.T) {
+	t.Parallel()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	})
+
+	// Setup
+	var (
+		client = http.DefaultClient
+		agent  = peer.NewUserAgent("", "")
+		logger = log.NewNopLogger()
+		server = httptest.NewServer(mux)
+	)
+	defer server.Close()
+
+	// Make sure we've got a valid url
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		var (
+			c           = NewCrawler(client, agent, false, false, 4, 2, logger)
+			ctx, cancel = context.WithCancel(context.Background())
+		)
+
+		done := make(chan error)
+		go func() { done <- c.Run(ctx, u) }()
+
+		time.AfterFunc(time.Millisecond*10, cancel)
+
+		select {
+		case err := <-done:
+			if _, ok := err.(*CancelledError); !ok {
+				t.Errorf("expected: CancelledError, actual: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected crawl to be cancelled")
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 4, 2, logger)
+
+		done := make(chan error)
+		go func() { done <- c.Run(context.Background(), u) }()
+
+		time.AfterFunc(time.Millisecond*10, c.Close)
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected crawl to be closed")
+		}
+	})
+}
+
 func TestGauge(t *testing.T) {
 	t.Parallel()
 
@@ -285,3 +754,258 @@ func TestGauge(t *testing.T) {
 		}
 	})
 }
+
+func TestCrawl_Stylesheets(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head>
+<link rel="stylesheet" href="/css/a.css" />
+<style>body { background: url(bg.png); }</style>
+</head><body><div style="background: url('/div.png')"></div></body></html>`)
+		case "/css/a.css":
+			w.Header().Set("Content-Type", "text/css; charset=utf-8")
+			fmt.Fprint(w, `@import "b.css"; @font-face { src: url(../fonts/a.woff2); }`)
+		case "/css/b.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, `.b { background: url("b.png"); }`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := func(enabled bool) Cache {
+		c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), false, false, 2, 2, log.NewNopLogger())
+		c.Filter(Addr(u))
+		c.Stylesheets(enabled)
+
+		if err := c.Run(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+		return c.cache
+	}
+
+	kinds := func(cache Cache, page string) map[string]document.Kind {
+		m, err := cache.Get(fmt.Sprintf("%s%s", u.String(), page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m.RefKinds
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		cache := fn(true)
+
+		for page, expected := range map[string]map[string]document.Kind{
+			"": {
+				"/css/a.css": document.KindStylesheet,
+				"/bg.png":    document.KindImage,
+				"/div.png":   document.KindImage,
+			},
+			"/css/a.css": {
+				"/css/b.css":     document.KindStylesheet,
+				"/fonts/a.woff2": document.KindFont,
+			},
+			"/css/b.css": {
+				"/css/b.png": document.KindImage,
+			},
+		} {
+			actual := kinds(cache, page)
+			for k, v := range expected {
+				if actual := actual[fmt.Sprintf("%s%s", u.String(), k)]; v != actual {
+					t.Errorf("expected: %s, actual: %s", v, actual)
+				}
+			}
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cache := fn(false)
+
+		if cache.Exists(fmt.Sprintf("%s/css/a.css", u.String())) {
+			t.Error("expected stylesheet not to be crawled")
+		}
+		if expected, actual := 1, len(kinds(cache, "")); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+}
+
+func TestCrawl_SaturatedHost(t *testing.T) {
+	t.Parallel()
+
+	var (
+		began     = time.Now()
+		requested = make(chan time.Duration, 1)
+	)
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- time.Since(began)
+	}))
+	defer other.Close()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			return
+		}
+		fmt.Fprint(w, "<html><body>")
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(w, `<a href="/%d">%d</a>`, i, i)
+		}
+		fmt.Fprintf(w, `<a href="%s">other</a></body></html>`, other.URL)
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), false, false, 4, 1, log.NewNopLogger())
+	c.Politeness(time.Millisecond * 100)
+	if err := c.Run(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	// The other host is requested straight away, rather than once every worker
+	// has waited its turn for the saturated host.
+	if actual := <-requested; actual > time.Millisecond*500 {
+		t.Errorf("expected: < 500ms, actual: %s", actual)
+	}
+}
+
+func TestCrawl_Nofollow(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><meta name="robots" content="noindex"></head><body>
+<a href="/follow">follow</a>
+<a href="/nofollow" rel="nofollow">nofollow</a>
+<a href="/header">header</a>
+</body></html>`)
+		case "/header":
+			w.Header().Set("X-Robots-Tag", "nofollow")
+			fmt.Fprint(w, `<a href="/page">page</a>`)
+		default:
+			fmt.Fprint(w, `<html></html>`)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := func(ignore bool) Cache {
+		c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), false, false, 2, 2, log.NewNopLogger())
+		c.Filter(Addr(u))
+		c.IgnoreNofollow(ignore)
+
+		if err := c.Run(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+		return c.cache
+	}
+
+	for _, v := range []struct {
+		name     string
+		ignore   bool
+		expected map[string]bool
+	}{
+		{"respected", false, map[string]bool{"/follow": true, "/header": true, "/nofollow": false, "/page": false}},
+		{"ignored", true, map[string]bool{"/follow": true, "/header": true, "/nofollow": true, "/page": true}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			cache := fn(v.ignore)
+
+			for page, expected := range v.expected {
+				if actual := cache.Exists(fmt.Sprintf("%s%s", u.String(), page)); expected != actual {
+					t.Errorf("%s expected: %t, actual: %t", page, expected, actual)
+				}
+			}
+
+			m, err := cache.Get(u.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !m.NoIndex {
+				t.Error("expected page to be noindex")
+			}
+		})
+	}
+}
+
+func TestCrawl_RecordReport(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<a href="/missing">missing</a><a href="http://other.com/">other</a><a href="mailto:a@b.com">mail</a><img src="/image.png">`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), false, false, 1, 1, log.NewNopLogger())
+	c.Filter(Addr(u))
+	c.Retry(RetryPolicy{MaxAttempts: 1})
+
+	if err := c.Run(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	records := c.RecordReport(time.Second).Records()
+	if expected, actual := 2, len(records); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+
+	root, missing := records[0], records[1]
+	if expected, actual := u.String(), root.URL; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	if expected, actual := http.StatusOK, root.StatusCode; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := "text/html; charset=utf-8", root.ContentType; expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+	if expected, actual := []string{u.String() + "/missing"}, root.RefLinks; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []string{"http://other.com/"}, root.RefExternalLinks; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []string{"mailto:a@b.com"}, root.RefRejectedLinks; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := map[string]string{
+		u.String() + "/missing":   "anchor",
+		u.String() + "/image.png": "image",
+	}, root.RefKinds; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := http.StatusNotFound, missing.StatusCode; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := 1, missing.Errorred; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
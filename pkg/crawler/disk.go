@@ -0,0 +1,408 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	// headerSize is the size of a record header: crc, key length, value
+	// length.
+	headerSize = 12
+
+	// defaultCompactSize is the size of the file before it's worth compacting.
+	defaultCompactSize = 64 << 20
+)
+
+// DiskCache is a Cache that stores the metrics in an append-only file, so the
+// size of a crawl isn't limited by memory and the metrics outlive the process.
+// Only the offsets of the metrics are held in memory, every Set appends a new
+// record and the last record for a url wins, a record without a value marks a
+// deleted url. Once the records that have been
+// replaced take up more of the file than the live records, the file is
+// compacted, as it is when the cache is closed.
+// Note: robots data can't be serialized, so the metrics of robots.txt urls are
+// only held in memory and the robots.txt is requested again once the cache is
+// reopened.
+type DiskCache struct {
+	mutex       sync.RWMutex
+	path        string
+	file        *os.File
+	size, live  int64
+	compactSize int64
+	index       map[string]record
+	robots      map[string]*Metric
+	logger      log.Logger
+}
+
+// record is the location of a metric value with in the file.
+type record struct {
+	offset int64
+	size   uint32
+}
+
+// start returns the offset of the header of the record with the key.
+func (r record) start(key string) int64 {
+	return r.offset - int64(headerSize+len(key))
+}
+
+// length returns the size of the whole record with the key.
+func (r record) length(key string) int64 {
+	return int64(headerSize+len(key)) + int64(r.size)
+}
+
+// NewDiskCache opens the cache file at path, creating it if it doesn't exist.
+// Any metrics already in the file are available from the cache.
+func NewDiskCache(path string, logger log.Logger) (*DiskCache, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open cache")
+	}
+
+	c := &DiskCache{
+		path:        path,
+		file:        file,
+		compactSize: defaultCompactSize,
+		index:       map[string]record{},
+		robots:      map[string]*Metric{},
+		logger:      logger,
+	}
+	if err := c.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Path returns the path of the cache file.
+func (c *DiskCache) Path() string {
+	return c.path
+}
+
+// Exists returns truthy if the value exists
+func (c *DiskCache) Exists(v string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if _, ok := c.robots[v]; ok {
+		return true
+	}
+	_, ok := c.index[v]
+	return ok
+}
+
+// Get a cache metric value based on the value.
+func (c *DiskCache) Get(v string) (*Metric, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if m, ok := c.robots[v]; ok {
+		return m, nil
+	}
+
+	r, ok := c.index[v]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return c.read(r)
+}
+
+// Set a cache metric based on the value.
+// Note: if the metric already exists it will over write it.
+func (c *DiskCache) Set(v string, m *Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.write(v, m)
+}
+
+// Update calls fn with the metric of the value, or a new metric if there isn't
+// one, then appends the metric to the file.
+func (c *DiskCache) Update(v string, fn func(*Metric, bool)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var (
+		m  *Metric
+		ok bool
+	)
+	if m, ok = c.robots[v]; !ok {
+		var r record
+		if r, ok = c.index[v]; ok {
+			var err error
+			if m, err = c.read(r); err != nil {
+				level.Error(c.logger).Log("url", v, "err", err)
+				return
+			}
+		}
+	}
+	if m == nil {
+		m = NewMetric()
+	}
+	fn(m, ok)
+	c.write(v, m)
+}
+
+// write appends the metric to the file.
+// Note: the mutex should be held when calling write.
+func (c *DiskCache) write(v string, m *Metric) {
+	if isRobotsTxt(v) {
+		c.robots[v] = m
+		return
+	}
+
+	value, err := json.Marshal(NewStateMetric(m))
+	if err != nil {
+		level.Error(c.logger).Log("url", v, "err", err)
+		return
+	}
+
+	buf := encodeRecord(v, value)
+	if _, err := c.file.WriteAt(buf, c.size); err != nil {
+		level.Error(c.logger).Log("url", v, "err", err)
+		return
+	}
+
+	if r, ok := c.index[v]; ok {
+		c.live -= r.length(v)
+	}
+	c.index[v] = record{
+		offset: c.size + int64(headerSize+len(v)),
+		size:   uint32(len(value)),
+	}
+	c.size += int64(len(buf))
+	c.live += int64(len(buf))
+
+	c.compactGarbage()
+}
+
+// Delete removes the metric of the value, appending a record without a value
+// so the metric stays deleted once the cache is reopened.
+func (c *DiskCache) Delete(v string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.robots[v]; ok {
+		delete(c.robots, v)
+		return
+	}
+
+	r, ok := c.index[v]
+	if !ok {
+		return
+	}
+
+	buf := encodeRecord(v, nil)
+	if _, err := c.file.WriteAt(buf, c.size); err != nil {
+		level.Error(c.logger).Log("url", v, "err", err)
+		return
+	}
+
+	delete(c.index, v)
+	c.live -= r.length(v)
+	c.size += int64(len(buf))
+
+	c.compactGarbage()
+}
+
+// compactGarbage compacts the file once the records that have been replaced
+// take up more of the file than the live records.
+// Note: the mutex should be held when calling compactGarbage.
+func (c *DiskCache) compactGarbage() {
+	if c.size > c.compactSize && c.size-c.live > c.live {
+		if err := c.compact(); err != nil {
+			level.Error(c.logger).Log("compact", c.path, "err", err)
+		}
+	}
+}
+
+// compact rewrites the file with only the live records, dropping every record
+// that has since been replaced.
+// Note: the mutex should be held when calling compact.
+func (c *DiskCache) compact() error {
+	tmp := c.path + ".compact"
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrap(err, "unable to create compacted cache")
+	}
+
+	var (
+		index = make(map[string]record, len(c.index))
+		size  int64
+	)
+	for k, r := range c.index {
+		buf := make([]byte, r.length(k))
+		if _, err := c.file.ReadAt(buf, r.start(k)); err != nil {
+			file.Close()
+			return errors.Wrap(err, "unable to read cache")
+		}
+		if _, err := file.WriteAt(buf, size); err != nil {
+			file.Close()
+			return errors.Wrap(err, "unable to write compacted cache")
+		}
+		index[k] = record{
+			offset: size + int64(headerSize+len(k)),
+			size:   r.size,
+		}
+		size += int64(len(buf))
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.Wrap(err, "unable to sync compacted cache")
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		file.Close()
+		return errors.Wrap(err, "unable to replace cache")
+	}
+
+	c.file.Close()
+	c.file = file
+	c.index = index
+	c.size = size
+	c.live = size
+	return nil
+}
+
+// encodeRecord encodes the key and value as a record, with a header holding
+// the checksum and the sizes of both.
+func encodeRecord(key string, value []byte) []byte {
+	buf := make([]byte, headerSize+len(key)+len(value))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(key)))
+	binary.BigEndian.PutUint32(buf[8:], uint32(len(value)))
+	copy(buf[headerSize:], key)
+	copy(buf[headerSize+len(key):], value)
+	binary.BigEndian.PutUint32(buf[0:], crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+// Range calls fn for every metric in the cache, stopping if fn returns false.
+func (c *DiskCache) Range(fn func(string, *Metric) bool) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for k, m := range c.robots {
+		if !fn(k, m) {
+			return nil
+		}
+	}
+	for k, r := range c.index {
+		m, err := c.read(r)
+		if err != nil {
+			return err
+		}
+		if !fn(k, m) {
+			break
+		}
+	}
+	return nil
+}
+
+// Sync commits the records of the cache to disk.
+func (c *DiskCache) Sync() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return errors.Wrap(c.file.Sync(), "unable to sync cache")
+}
+
+// Close compacts and syncs the cache to disk and closes the file.
+func (c *DiskCache) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.size > c.live {
+		if err := c.compact(); err != nil {
+			c.file.Close()
+			return err
+		}
+	}
+
+	if err := c.file.Sync(); err != nil {
+		c.file.Close()
+		return errors.Wrap(err, "unable to sync cache")
+	}
+	return c.file.Close()
+}
+
+// read a metric value from the file.
+// Note: the mutex should be held when calling read.
+func (c *DiskCache) read(r record) (*Metric, error) {
+	buf := make([]byte, r.size)
+	if _, err := c.file.ReadAt(buf, r.offset); err != nil {
+		return nil, errors.Wrap(err, "unable to read cache")
+	}
+
+	var s StateMetric
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return nil, errors.Wrap(err, "invalid cache metric")
+	}
+	return s.Metric(), nil
+}
+
+// load builds the index from the records in the file. A partially written
+// record at the end of the file, for example after a crash, is discarded.
+func (c *DiskCache) load() error {
+	var (
+		reader = bufio.NewReader(c.file)
+		header = make([]byte, headerSize)
+	)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break
+		}
+
+		var (
+			sum       = binary.BigEndian.Uint32(header[0:])
+			keySize   = binary.BigEndian.Uint32(header[4:])
+			valueSize = binary.BigEndian.Uint32(header[8:])
+			body      = make([]byte, keySize+valueSize)
+		)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			break
+		}
+
+		hash := crc32.NewIEEE()
+		hash.Write(header[4:])
+		hash.Write(body)
+		if hash.Sum32() != sum {
+			break
+		}
+
+		length := int64(headerSize) + int64(len(body))
+		key := string(body[:keySize])
+		if r, ok := c.index[key]; ok {
+			c.live -= r.length(key)
+			delete(c.index, key)
+		}
+		if valueSize > 0 {
+			c.index[key] = record{
+				offset: c.size + int64(headerSize+keySize),
+				size:   valueSize,
+			}
+			c.live += length
+		}
+		c.size += length
+	}
+
+	if err := c.file.Truncate(c.size); err != nil {
+		return errors.Wrap(err, "unable to truncate cache")
+	}
+	return nil
+}
+
+// isRobotsTxt returns if the url is that of a robots.txt.
+func isRobotsTxt(v string) bool {
+	u, err := url.Parse(v)
+	return err == nil && u.Path == defaultRobotsTxt
+}
@@ -0,0 +1,287 @@
+package crawler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+
+	"github.com/SimonRichardson/crwlr/pkg/test"
+	"github.com/go-kit/kit/log"
+	"github.com/temoto/robotstxt"
+)
+
+func newDiskCache(t *testing.T) (*DiskCache, string, func()) {
+	dir, err := ioutil.TempDir("", "crwlr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "cache")
+	cache, err := NewDiskCache(path, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache, path, func() {
+		cache.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("set get", func(t *testing.T) {
+		cache, _, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		fn := func(a test.ASCII) bool {
+			metric := NewMetric()
+			metric.Received.Increment()
+			metric.AppendRefLink(a.String())
+			cache.Set(a.String(), metric)
+
+			m, err := cache.Get(a.String())
+			if err != nil {
+				t.Error(err)
+				return false
+			}
+			return cache.Exists(a.String()) &&
+				m.Received.Time() == 1 &&
+				len(m.RefLinks) == 1 &&
+				m.RefLinks[0] == a.String()
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("get empty", func(t *testing.T) {
+		cache, _, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		if cache.Exists("http://a.com") {
+			t.Error("expected not to exist")
+		}
+		if _, err := cache.Get("http://a.com"); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		cache, _, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		metric := NewMetric()
+		cache.Set("http://a.com", metric)
+		metric.Requested.Increment()
+		cache.Set("http://a.com", metric)
+
+		m, err := cache.Get("http://a.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(1), m.Requested.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		cache, _, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		testCacheUpdate(t, cache)
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		cache, path, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		for _, v := range []string{"http://a.com", "http://b.com", "http://a.com"} {
+			metric := NewMetric()
+			metric.Received.Increment()
+			cache.Set(v, metric)
+		}
+		if err := cache.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Simulate a partially written record.
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte{0, 1, 2, 3, 4})
+		file.Close()
+
+		cache, err = NewDiskCache(path, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cache.Close()
+
+		var urls int
+		err = cache.Range(func(v string, m *Metric) bool {
+			urls++
+			if expected, actual := int64(1), m.Received.Time(); expected != actual {
+				t.Errorf("expected: %d, actual: %d", expected, actual)
+			}
+			return true
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if expected, actual := 2, urls; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+
+		// New records are written after the last valid record.
+		cache.Set("http://c.com", NewMetric())
+		if _, err := cache.Get("http://c.com"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		cache, path, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		cache.Set("http://a.com", NewMetric())
+		cache.Set("http://b.com", NewMetric())
+		cache.Delete("http://a.com")
+
+		if cache.Exists("http://a.com") {
+			t.Error("expected not to exist")
+		}
+		if err := cache.Sync(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Deleted urls stay deleted once the cache is reopened.
+		reopened, err := NewDiskCache(path, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reopened.Close()
+
+		if reopened.Exists("http://a.com") {
+			t.Error("expected not to exist")
+		}
+		if !reopened.Exists("http://b.com") {
+			t.Error("expected to exist")
+		}
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		cache, path, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		cache.compactSize = 1024
+
+		for i := 0; i < 100; i++ {
+			cache.Update("http://a.com", func(m *Metric, _ bool) {
+				m.Filtered.Increment()
+			})
+		}
+		cache.Set("http://b.com", NewMetric())
+
+		// Compacting past the garbage ratio keeps the file small.
+		if cache.size > 2*cache.compactSize {
+			t.Errorf("expected: <= %d, actual: %d", 2*cache.compactSize, cache.size)
+		}
+
+		for i := 0; i < 10; i++ {
+			cache.Update("http://b.com", func(m *Metric, _ bool) {
+				m.Requested.Increment()
+			})
+		}
+		live := cache.live
+		if err := cache.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Closing compacts the file down to the live records.
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := live, info.Size(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+
+		cache, err = NewDiskCache(path, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cache.Close()
+
+		a, err := cache.Get("http://a.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(100), a.Filtered.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		b, err := cache.Get("http://b.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(10), b.Requested.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("robots", func(t *testing.T) {
+		cache, path, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		robots, err := robotstxt.FromString("User-agent: *\nDisallow: /private")
+		if err != nil {
+			t.Fatal(err)
+		}
+		metric := NewMetric()
+		metric.Received.Increment()
+		metric.Robots = robots
+		cache.Set("http://a.com/robots.txt", metric)
+		cache.Set("http://a.com", NewMetric())
+
+		m, err := cache.Get("http://a.com/robots.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Robots == nil {
+			t.Error("expected robots data")
+		}
+
+		var urls int
+		if err := cache.Range(func(string, *Metric) bool {
+			urls++
+			return true
+		}); err != nil {
+			t.Error(err)
+		}
+		if expected, actual := 2, urls; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+
+		if err := cache.Close(); err != nil {
+			t.Fatal(err)
+		}
+		cache, err = NewDiskCache(path, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cache.Close()
+
+		// The robots.txt has to be requested again, rather than allowing
+		// everything.
+		if cache.Exists("http://a.com/robots.txt") {
+			t.Error("expected robots.txt not to exist")
+		}
+		if !cache.Exists("http://a.com") {
+			t.Error("expected to exist")
+		}
+	})
+}
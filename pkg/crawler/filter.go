@@ -1,6 +1,9 @@
 package crawler
 
-import "net/url"
+import (
+	"net/url"
+	"strings"
+)
 
 // Filter describes a way of filtering what urls should and should not be
 // crawled.
@@ -10,17 +13,18 @@ type Filter interface {
 }
 
 type addr struct {
-	u *url.URL
+	host string
 }
 
 func (a addr) Valid(u *url.URL) bool {
-	return a.u.Host == u.Host
+	return a.host == canonicalHost(strings.ToLower(u.Scheme), u.Host)
 }
 
 // Addr returns a Filter that filters out urls that should be crawled by
-// the addr name of a url host (accepts host:port)
+// the addr name of a url host (accepts host:port). Hosts are compared in
+// their canonical form, so the case and any default port are ignored.
 func Addr(u *url.URL) Filter {
-	return addr{u}
+	return addr{canonicalHost(strings.ToLower(u.Scheme), u.Host)}
 }
 
 type fn struct {
@@ -62,6 +62,28 @@ func TestFilterAddr(t *testing.T) {
 			t.Error(err)
 		}
 	})
+
+	t.Run("Canonical", func(t *testing.T) {
+		for _, v := range []struct {
+			addr, url string
+		}{
+			{"http://LOCALHOST:8080", "http://localhost:8080/a"},
+			{"http://a.com:80", "http://a.com/a"},
+			{"https://A.com:443", "https://a.com/a"},
+			{"http://a.com", "HTTP://a.COM:80/a"},
+		} {
+			var (
+				h, err0 = url.Parse(v.addr)
+				u, err1 = url.Parse(v.url)
+			)
+			if err0 != nil || err1 != nil {
+				t.Fatalf("errors %v %v", err0, err1)
+			}
+			if !Addr(h).Valid(u) {
+				t.Errorf("expected: %s to be valid for %s", v.url, v.addr)
+			}
+		}
+	})
 }
 
 var result bool
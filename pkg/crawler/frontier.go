@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"time"
+)
+
+// Frontier holds the items that are waiting to be crawled. The order in which
+// items are removed from the Frontier defines the strategy of the crawl.
+// Note: a Frontier isn't expected to be safe for concurrent use, the Crawler
+// guards it.
+type Frontier interface {
+	// Push adds an item to the Frontier.
+	Push(Item)
+
+	// Pop removes the next item to crawl, returning false if the Frontier is
+	// empty.
+	Pop() (Item, bool)
+
+	// Len returns the number of items in the Frontier.
+	Len() int
+
+	// Items returns a copy of the items in the Frontier, in the order that
+	// they were pushed. Pushing the items in to an empty Frontier recreates
+	// the same Frontier.
+	Items() []Item
+}
+
+type fifo struct {
+	items []Item
+}
+
+// NewFIFO returns a Frontier that crawls items in the order they were
+// discovered (breadth-first).
+func NewFIFO() Frontier {
+	return &fifo{[]Item{}}
+}
+
+func (f *fifo) Push(i Item) {
+	f.items = append(f.items, i)
+}
+
+func (f *fifo) Pop() (Item, bool) {
+	if len(f.items) == 0 {
+		return Item{}, false
+	}
+
+	i := f.items[0]
+	f.items[0] = Item{}
+	f.items = f.items[1:]
+	return i, true
+}
+
+func (f *fifo) Len() int {
+	return len(f.items)
+}
+
+func (f *fifo) Items() []Item {
+	return append([]Item{}, f.items...)
+}
+
+type lifo struct {
+	items []Item
+}
+
+// NewLIFO returns a Frontier that crawls the most recently discovered items
+// first (depth-first).
+func NewLIFO() Frontier {
+	return &lifo{[]Item{}}
+}
+
+func (f *lifo) Push(i Item) {
+	f.items = append(f.items, i)
+}
+
+func (f *lifo) Pop() (Item, bool) {
+	n := len(f.items)
+	if n == 0 {
+		return Item{}, false
+	}
+
+	i := f.items[n-1]
+	f.items[n-1] = Item{}
+	f.items = f.items[:n-1]
+	return i, true
+}
+
+func (f *lifo) Len() int {
+	return len(f.items)
+}
+
+func (f *lifo) Items() []Item {
+	return append([]Item{}, f.items...)
+}
+
+// Score returns the priority of an item, items with a higher score are crawled
+// first.
+type Score func(Item) float64
+
+// Shallow scores items that are closest to the seed url the highest.
+func Shallow(i Item) float64 {
+	return -float64(i.Depth)
+}
+
+// SitemapPriority scores items by their sitemap priority, so items that
+// aren't in a sitemap are crawled last. Items that were modified recently are
+// nudged ahead of items with the same priority.
+func SitemapPriority(i Item) float64 {
+	score := i.Priority
+	if !i.LastMod.IsZero() {
+		days := math.Max(time.Since(i.LastMod).Hours()/24, 0)
+		score += 0.05 / (1 + days)
+	}
+	return score
+}
+
+type priority struct {
+	queue *priorityQueue
+}
+
+// NewPriority returns a Frontier that crawls the items with the highest score
+// first. Items with the same score are crawled in the order they were
+// discovered.
+func NewPriority(score Score) Frontier {
+	return &priority{
+		queue: &priorityQueue{score: score},
+	}
+}
+
+func (f *priority) Push(i Item) {
+	heap.Push(f.queue, i)
+}
+
+func (f *priority) Pop() (Item, bool) {
+	if f.queue.Len() == 0 {
+		return Item{}, false
+	}
+	return heap.Pop(f.queue).(Item), true
+}
+
+func (f *priority) Len() int {
+	return f.queue.Len()
+}
+
+func (f *priority) Items() []Item {
+	items := make([]scored, len(f.queue.items))
+	copy(items, f.queue.items)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].seq < items[j].seq
+	})
+
+	res := make([]Item, len(items))
+	for k, v := range items {
+		res[k] = v.item
+	}
+	return res
+}
+
+type scored struct {
+	item  Item
+	score float64
+	seq   uint64
+}
+
+// priorityQueue implements heap.Interface
+type priorityQueue struct {
+	score Score
+	items []scored
+	seq   uint64
+}
+
+func (q *priorityQueue) Len() int { return len(q.items) }
+
+func (q *priorityQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.score == b.score {
+		return a.seq < b.seq
+	}
+	return a.score > b.score
+}
+
+func (q *priorityQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+}
+
+func (q *priorityQueue) Push(x interface{}) {
+	i := x.(Item)
+	q.items = append(q.items, scored{
+		item:  i,
+		score: q.score(i),
+		seq:   q.seq,
+	})
+	q.seq++
+}
+
+func (q *priorityQueue) Pop() interface{} {
+	n := len(q.items)
+	s := q.items[n-1]
+	q.items[n-1] = scored{}
+	q.items = q.items[:n-1]
+	return s.item
+}
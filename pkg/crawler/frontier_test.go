@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func items(depths ...int) []Item {
+	res := make([]Item, len(depths))
+	for k, v := range depths {
+		u, _ := url.Parse(fmt.Sprintf("http://a.com/%d", k))
+		res[k] = Item{URL: u, Depth: v}
+	}
+	return res
+}
+
+func drain(f Frontier) []string {
+	var res []string
+	for {
+		i, ok := f.Pop()
+		if !ok {
+			return res
+		}
+		res = append(res, i.URL.Path)
+	}
+}
+
+func TestFrontier(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fifo", func(t *testing.T) {
+		f := NewFIFO()
+		for _, v := range items(0, 1, 2) {
+			f.Push(v)
+		}
+
+		if expected, actual := []string{"/0", "/1", "/2"}, drain(f); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("lifo", func(t *testing.T) {
+		f := NewLIFO()
+		for _, v := range items(0, 1, 2) {
+			f.Push(v)
+		}
+
+		if expected, actual := []string{"/2", "/1", "/0"}, drain(f); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		f := NewPriority(Shallow)
+		for _, v := range items(2, 0, 1, 0) {
+			f.Push(v)
+		}
+
+		if expected, actual := []string{"/1", "/3", "/2", "/0"}, drain(f); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("sitemap priority", func(t *testing.T) {
+		f := NewPriority(SitemapPriority)
+		for k, v := range items(0, 0, 0, 0) {
+			switch k {
+			case 1:
+				v.Priority = 0.8
+			case 2:
+				v.Priority, v.LastMod = 0.5, time.Now().Add(-time.Hour)
+			case 3:
+				v.Priority, v.LastMod = 0.5, time.Now().Add(-24*time.Hour*365)
+			}
+			f.Push(v)
+		}
+
+		if expected, actual := []string{"/1", "/2", "/3", "/0"}, drain(f); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("items", func(t *testing.T) {
+		for _, fn := range []func() Frontier{
+			NewFIFO,
+			NewLIFO,
+			func() Frontier { return NewPriority(Shallow) },
+		} {
+			f := fn()
+			for _, v := range items(2, 0, 1, 0) {
+				f.Push(v)
+			}
+			f.Pop()
+
+			// Pushing the items in to a new frontier recreates the same order.
+			g := fn()
+			for _, v := range f.Items() {
+				g.Push(v)
+			}
+
+			if expected, actual := drain(f), drain(g); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		}
+	})
+
+	t.Run("len", func(t *testing.T) {
+		fn := func(a uint) bool {
+			n := int(a % 1000)
+			for _, f := range []Frontier{NewFIFO(), NewLIFO(), NewPriority(Shallow)} {
+				for i := 0; i < n; i++ {
+					f.Push(Item{})
+				}
+				if f.Len() != n {
+					return false
+				}
+				for i := 0; i < n; i++ {
+					f.Pop()
+				}
+				if _, ok := f.Pop(); ok || f.Len() != 0 {
+					return false
+				}
+			}
+			return true
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func BenchmarkPriority(b *testing.B) {
+	f := NewPriority(Shallow)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		f.Push(Item{Depth: i % 10})
+	}
+	for i := 0; i < b.N; i++ {
+		f.Pop()
+	}
+}
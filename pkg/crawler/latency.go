@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Latency is the time spent in each phase of a request. Phases that didn't
+// happen, for example the DNS lookup of a reused connection, are zero.
+type Latency struct {
+	DNS     time.Duration `json:"dns,omitempty"`
+	Connect time.Duration `json:"connect,omitempty"`
+	TLS     time.Duration `json:"tls,omitempty"`
+	// TTFB is the time from having a connection to the first byte of the
+	// response.
+	TTFB time.Duration `json:"ttfb,omitempty"`
+	// Body is the time from the first byte to reading all of the body.
+	Body  time.Duration `json:"body,omitempty"`
+	Total time.Duration `json:"total"`
+	Bytes int64         `json:"bytes"`
+}
+
+// latencyTrace records the phases of a request using a httptrace.ClientTrace.
+type latencyTrace struct {
+	mutex                                sync.Mutex
+	began, dns, connect, tls, conn, ttfb time.Time
+	latency                              Latency
+}
+
+func newLatencyTrace() *latencyTrace {
+	return &latencyTrace{
+		began: time.Now(),
+	}
+}
+
+// WithContext returns a context that traces the requests made with it.
+func (t *latencyTrace) WithContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.start(&t.dns)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.done(&t.dns, &t.latency.DNS)
+		},
+		ConnectStart: func(string, string) {
+			t.start(&t.connect)
+		},
+		ConnectDone: func(string, string, error) {
+			t.done(&t.connect, &t.latency.Connect)
+		},
+		TLSHandshakeStart: func() {
+			t.start(&t.tls)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.done(&t.tls, &t.latency.TLS)
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			t.start(&t.conn)
+		},
+		GotFirstResponseByte: func() {
+			t.start(&t.ttfb)
+			t.done(&t.conn, &t.latency.TTFB)
+		},
+	})
+}
+
+// Done returns the latency of the request, once the body has been read.
+func (t *latencyTrace) Done(bytes int) Latency {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	if !t.ttfb.IsZero() {
+		t.latency.Body = now.Sub(t.ttfb)
+	}
+	t.latency.Total = now.Sub(t.began)
+	t.latency.Bytes = int64(bytes)
+	return t.latency
+}
+
+func (t *latencyTrace) start(at *time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	*at = time.Now()
+}
+
+// done records the time since the phase started. A phase can happen more than
+// once, for example connecting to both ipv4 and ipv6 addresses, in which case
+// the longest is kept.
+func (t *latencyTrace) done(began *time.Time, phase *time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if began.IsZero() {
+		return
+	}
+	if d := time.Since(*began); d > *phase {
+		*phase = d
+	}
+}
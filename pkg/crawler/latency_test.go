@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/go-kit/kit/log"
+)
+
+func TestCrawl_Latency(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, `<html><body>hello</body></html>`)
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), false, false, 1, 1, log.NewNopLogger())
+	c.Filter(Addr(u))
+	if err := c.Run(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := c.cache.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 1, len(m.Latencies); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+
+	l := m.Latencies[0]
+	if expected, actual := int64(len(`<html><body>hello</body></html>`)), l.Bytes; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if l.TTFB < 10*time.Millisecond {
+		t.Errorf("expected: >= %v, actual: %v", 10*time.Millisecond, l.TTFB)
+	}
+	if l.Total < l.TTFB+l.Body {
+		t.Errorf("expected: >= %v, actual: %v", l.TTFB+l.Body, l.Total)
+	}
+}
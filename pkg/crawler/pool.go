@@ -0,0 +1,93 @@
+package crawler
+
+import "sync"
+
+// Pool runs a fixed number of workers and bounds how many of those workers
+// can be requesting from the same host at any one time.
+type Pool struct {
+	mutex       sync.Mutex
+	cond        *sync.Cond
+	size        int
+	sizePerHost int
+	hosts       map[string]int
+}
+
+// NewPool creates a Pool with a maximum amount of workers (size) and a
+// maximum amount of those workers that can be in-flight for a single host
+// (sizePerHost).
+// Note: a size less than 1 is treated as 1 and a sizePerHost less than 1 means
+// there is no per host limit.
+func NewPool(size, sizePerHost int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	p := &Pool{
+		size:        size,
+		sizePerHost: sizePerHost,
+		hosts:       map[string]int{},
+	}
+	p.cond = sync.NewCond(&p.mutex)
+	return p
+}
+
+// Size returns the number of workers the Pool runs.
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Run executes the worker fn for every worker in the pool and only returns
+// once all the workers have returned.
+func (p *Pool) Run(fn func()) {
+	wg := sync.WaitGroup{}
+	wg.Add(p.size)
+	for i := 0; i < p.size; i++ {
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
+
+// Acquire blocks until there is a free slot for the host.
+func (p *Pool) Acquire(host string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for p.sizePerHost > 0 && p.hosts[host] >= p.sizePerHost {
+		p.cond.Wait()
+	}
+	p.hosts[host]++
+}
+
+// TryAcquire acquires a slot for the host without blocking, returning false if
+// there are no free slots for the host.
+func (p *Pool) TryAcquire(host string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.sizePerHost > 0 && p.hosts[host] >= p.sizePerHost {
+		return false
+	}
+	p.hosts[host]++
+	return true
+}
+
+// Release frees up a slot for the host, so that another worker can acquire it.
+func (p *Pool) Release(host string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.hosts[host]--; p.hosts[host] < 1 {
+		delete(p.hosts, host)
+	}
+	p.cond.Broadcast()
+}
+
+// InFlight returns how many slots are currently acquired for the host.
+func (p *Pool) InFlight(host string) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.hosts[host]
+}
@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("size", func(t *testing.T) {
+		fn := func(a uint) bool {
+			var (
+				size    = int(a%100) + 1
+				pool    = NewPool(size, 0)
+				workers int64
+			)
+			pool.Run(func() {
+				atomic.AddInt64(&workers, 1)
+			})
+			return int(workers) == size
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("minimum size", func(t *testing.T) {
+		if expected, actual := 1, NewPool(0, 0).Size(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("per host", func(t *testing.T) {
+		var (
+			pool = NewPool(10, 2)
+
+			mutex sync.Mutex
+			max   int
+		)
+
+		pool.Run(func() {
+			pool.Acquire("a.com")
+			defer pool.Release("a.com")
+
+			mutex.Lock()
+			if n := pool.InFlight("a.com"); n > max {
+				max = n
+			}
+			mutex.Unlock()
+
+			time.Sleep(time.Millisecond)
+		})
+
+		if max > 2 {
+			t.Errorf("expected: <= 2, actual: %d", max)
+		}
+		if expected, actual := 0, pool.InFlight("a.com"); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("other hosts", func(t *testing.T) {
+		pool := NewPool(2, 1)
+		pool.Acquire("a.com")
+
+		done := make(chan struct{})
+		go func() {
+			pool.Acquire("b.com")
+			pool.Release("b.com")
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Error("expected other hosts not to be blocked")
+		}
+		pool.Release("a.com")
+	})
+
+	t.Run("try acquire", func(t *testing.T) {
+		pool := NewPool(2, 1)
+		if !pool.TryAcquire("a.com") {
+			t.Error("expected to acquire a.com")
+		}
+		if pool.TryAcquire("a.com") {
+			t.Error("expected a.com to be saturated")
+		}
+		if !pool.TryAcquire("b.com") {
+			t.Error("expected to acquire b.com")
+		}
+
+		pool.Release("a.com")
+		if !pool.TryAcquire("a.com") {
+			t.Error("expected to acquire a.com once released")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// maxRedirects is how many redirects are followed before giving up, the same
+// as the default of http.Client.
+const maxRedirects = 10
+
+// ErrRedirectLoop is returned when a redirect leads back to a url that has
+// already been requested.
+var ErrRedirectLoop = errors.New("redirect loop")
+
+// Redirect is a hop of a redirect chain.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+type redirectKey struct{}
+
+// redirectChain records the redirects of a request.
+type redirectChain struct {
+	mutex sync.Mutex
+	hops  []Redirect
+}
+
+// WithContext returns a context that records the redirects of the requests
+// made with it.
+func (r *redirectChain) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, redirectKey{}, r)
+}
+
+// Hops returns the redirects that were followed.
+func (r *redirectChain) Hops() []Redirect {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]Redirect{}, r.hops...)
+}
+
+func (r *redirectChain) append(hop Redirect) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.hops = append(r.hops, hop)
+}
+
+// redirectClient returns a copy of the client that records the redirects of
+// every request in to the redirectChain of the request context. Only the
+// redirects that are followed, or that close a loop, are recorded. Redirect
+// loops are stopped as soon as they're found, rather than following them
+// until the limit is reached.
+func redirectClient(client *http.Client) *http.Client {
+	res := *client
+	check := client.CheckRedirect
+	res.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// The hop that closes a loop is recorded, so the loop can be reported.
+		err := checkRedirect(check, req, via)
+		if err != nil && err != ErrRedirectLoop {
+			return err
+		}
+
+		if chain, ok := req.Context().Value(redirectKey{}).(*redirectChain); ok {
+			hop := Redirect{
+				URL:      via[len(via)-1].URL.String(),
+				Location: req.URL.String(),
+			}
+			if req.Response != nil {
+				hop.StatusCode = req.Response.StatusCode
+			}
+			chain.append(hop)
+		}
+		return err
+	}
+	return &res
+}
+
+// checkRedirect returns an error if the redirect shouldn't be followed. The
+// check of the client goes first, so a client that doesn't follow redirects
+// gets the last response, even for a redirect loop.
+func checkRedirect(check func(*http.Request, []*http.Request) error, req *http.Request, via []*http.Request) error {
+	if check != nil {
+		if err := check(req, via); err != nil {
+			return err
+		}
+	} else if len(via) >= maxRedirects {
+		return errors.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	for _, v := range via {
+		if v.URL.String() == req.URL.String() {
+			return ErrRedirectLoop
+		}
+	}
+	return nil
+}
+
+// selectHeaders returns the first value of each of the names in the header.
+func selectHeaders(header http.Header, names []string) map[string]string {
+	res := map[string]string{}
+	for _, v := range names {
+		if value := header.Get(v); value != "" {
+			res[http.CanonicalHeaderKey(v)] = value
+		}
+	}
+	return res
+}
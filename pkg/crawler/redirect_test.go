@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/go-kit/kit/log"
+)
+
+func TestCrawl_Redirects(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<a href="/moved">moved</a><a href="/loop">loop</a>`)
+		case "/moved":
+			http.Redirect(w, r, "/found", http.StatusMovedPermanently)
+		case "/found":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop2", http.StatusFound)
+		case "/loop2":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			w.Header().Set("Cache-Control", "no-cache")
+			fmt.Fprint(w, `<html></html>`)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), false, false, 1, 1, log.NewNopLogger())
+	c.Filter(Addr(u))
+	c.Retry(RetryPolicy{MaxAttempts: 1})
+	c.Headers([]string{"cache-control", "x-missing"})
+
+	if err := c.Run(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) *Metric {
+		m, err := c.cache.Get(u.String() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	t.Run("chain", func(t *testing.T) {
+		m := get("/moved")
+
+		expected := []Redirect{
+			{u.String() + "/moved", http.StatusMovedPermanently, u.String() + "/found"},
+			{u.String() + "/found", http.StatusFound, u.String() + "/page"},
+		}
+		if actual := m.Redirects; !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+		if expected, actual := http.StatusOK, m.StatusCode; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := int64(len(`<html></html>`)), m.ContentLength; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := map[string]string{"Cache-Control": "no-cache"}, m.Headers; !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		m := get("/loop")
+
+		if expected, actual := 2, len(m.Redirects); expected != actual {
+			t.Fatalf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := u.String()+"/loop", m.Redirects[1].Location; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+		if expected, actual := int64(1), m.Errorred.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		r := c.StatusReport(1)
+
+		if expected, actual := []string{u.String() + "/loop", u.String() + "/moved"}, r.Flagged(); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+		if expected, actual := map[string]int{"2xx": 2, "none": 1}, r.Classes(); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+}
+
+func TestRedirectClient_NoFollow(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path, http.StatusFound)
+	}))
+	defer site.Close()
+
+	client := redirectClient(&http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	})
+
+	chain := &redirectChain{}
+	req, err := http.NewRequest(http.MethodGet, site.URL+"/self", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req.WithContext(chain.WithContext(context.Background())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if expected, actual := http.StatusFound, resp.StatusCode; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := 0, len(chain.Hops()); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
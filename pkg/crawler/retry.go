@@ -0,0 +1,123 @@
+package crawler
+
+import (
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy defines how requests that fail for transient reasons are
+// retried. The zero value of a RetryPolicy never retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseBackoff is the backoff before the first retry, it's doubled on every
+	// following retry up to the MaxBackoff.
+	BaseBackoff, MaxBackoff time.Duration
+	// Jitter is the fraction (0-1) of the backoff that is randomised, to
+	// prevent retries from being sent in lock step.
+	Jitter float64
+	// StatusCodes are the response status codes that can be retried.
+	StatusCodes []int
+	// Timeouts defines if requests that timed out can be retried.
+	Timeouts bool
+	// Resets defines if requests where the connection was reset, refused or
+	// closed early can be retried.
+	Resets bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy that retries common transient
+// failures up to 3 times.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		Jitter:      0.5,
+		StatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		Timeouts: true,
+		Resets:   true,
+	}
+}
+
+// Retry returns if another attempt should be made, given the number of
+// attempts made so far and the error of the last attempt.
+func (p RetryPolicy) Retry(attempt int, err error) bool {
+	if err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	return p.Retryable(err)
+}
+
+// Retryable returns if the error is considered transient by the policy.
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if e, ok := errors.Cause(err).(*StatusError); ok {
+		for _, v := range p.StatusCodes {
+			if v == e.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return p.Timeouts
+	}
+
+	switch networkCause(err) {
+	case syscall.ECONNRESET, syscall.ECONNREFUSED, io.EOF, io.ErrUnexpectedEOF:
+		return p.Resets
+	}
+	return false
+}
+
+// Backoff returns how long to wait before making the next attempt.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := p.BaseBackoff
+	for i := 1; i < attempt && (p.MaxBackoff < 1 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		backoff -= time.Duration(p.Jitter * rand.Float64() * float64(backoff))
+	}
+	return backoff
+}
+
+// networkCause unwraps the errors returned from a http.Client, so that the
+// underlying network error can be inspected.
+func networkCause(err error) error {
+	for {
+		switch e := err.(type) {
+		case *url.Error:
+			err = e.Err
+		case *net.OpError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	reset := &url.Error{
+		Op:  "Get",
+		URL: "http://a.com",
+		Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+	}
+
+	t.Run("zero value", func(t *testing.T) {
+		var policy RetryPolicy
+		if policy.Retry(1, reset) {
+			t.Error("expected zero value not to retry")
+		}
+	})
+
+	t.Run("retryable", func(t *testing.T) {
+		policy := DefaultRetryPolicy()
+
+		for _, testcase := range []struct {
+			err      error
+			expected bool
+		}{
+			{nil, false},
+			{errors.New("unknown"), false},
+			{&StatusError{StatusCode: http.StatusNotFound}, false},
+			{&StatusError{StatusCode: http.StatusServiceUnavailable}, true},
+			{errors.Wrap(&StatusError{StatusCode: http.StatusBadGateway}, "wrapped"), true},
+			{&url.Error{Op: "Get", URL: "http://a.com", Err: timeoutError{}}, true},
+			{&url.Error{Op: "Get", URL: "http://a.com", Err: io.ErrUnexpectedEOF}, true},
+			{reset, true},
+		} {
+			if actual := policy.Retryable(testcase.err); testcase.expected != actual {
+				t.Errorf("(%v): expected: %t, actual: %t", testcase.err, testcase.expected, actual)
+			}
+		}
+	})
+
+	t.Run("disabled network errors", func(t *testing.T) {
+		policy := DefaultRetryPolicy()
+		policy.Timeouts = false
+		policy.Resets = false
+
+		if policy.Retryable(reset) {
+			t.Error("expected resets not to be retried")
+		}
+		if policy.Retryable(&url.Error{Op: "Get", URL: "http://a.com", Err: timeoutError{}}) {
+			t.Error("expected timeouts not to be retried")
+		}
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		policy := DefaultRetryPolicy()
+		if !policy.Retry(policy.MaxAttempts-1, reset) {
+			t.Error("expected to retry")
+		}
+		if policy.Retry(policy.MaxAttempts, reset) {
+			t.Error("expected not to retry once max attempts reached")
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		policy := RetryPolicy{
+			BaseBackoff: time.Second,
+			MaxBackoff:  time.Second * 5,
+		}
+
+		for attempt, expected := range []time.Duration{
+			time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5,
+		} {
+			if actual := policy.Backoff(attempt + 1); expected != actual {
+				t.Errorf("(%d): expected: %s, actual: %s", attempt+1, expected, actual)
+			}
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		fn := func(a uint) bool {
+			var (
+				attempt = int(a%10) + 1
+				policy  = RetryPolicy{
+					BaseBackoff: time.Second,
+					MaxBackoff:  time.Minute,
+					Jitter:      0.5,
+				}
+				backoff = policy.Backoff(attempt)
+				max     = RetryPolicy{BaseBackoff: time.Second, MaxBackoff: time.Minute}.Backoff(attempt)
+			)
+			return backoff <= max && backoff >= max/2
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scheduler keeps track of when each host is next allowed to be requested, so
+// that being polite to one host doesn't hold up requesting any other hosts.
+type Scheduler struct {
+	mutex sync.Mutex
+	delay time.Duration
+	hosts map[string]*schedule
+}
+
+type schedule struct {
+	next     time.Time
+	delay    time.Duration
+	throttle time.Duration
+}
+
+// NewScheduler creates a Scheduler with a default minimum delay between
+// requests to the same host.
+func NewScheduler(delay time.Duration) *Scheduler {
+	return &Scheduler{
+		delay: delay,
+		hosts: map[string]*schedule{},
+	}
+}
+
+// Delay sets the delay between requests for a host, for example the robots.txt
+// Crawl-delay. The delay will never be less than the default minimum delay.
+func (s *Scheduler) Delay(host string, delay time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if delay < s.delay {
+		delay = s.delay
+	}
+	s.host(host).delay = delay
+}
+
+// Throttle sets an additional delay between requests for a host, so that a
+// struggling host can be slowed down without losing the original delay.
+// The larger of the delay and the throttle is used.
+func (s *Scheduler) Throttle(host string, throttle time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.host(host).throttle = throttle
+}
+
+// Backoff prevents the host from being requested until the given time. If the
+// host is already blocked for longer than the time, Backoff does nothing.
+func (s *Scheduler) Backoff(host string, until time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if h := s.host(host); h.next.Before(until) {
+		h.next = until
+	}
+}
+
+// Next returns the time when the host is next allowed to be requested.
+func (s *Scheduler) Next(host string) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.host(host).next
+}
+
+// Wait blocks until the host is allowed to be requested, reserving the slot so
+// that the following request for the same host has to wait for the delay.
+// If the context is done before the host is allowed, the context error is
+// returned.
+func (s *Scheduler) Wait(ctx context.Context, host string) error {
+	s.mutex.Lock()
+	var (
+		h   = s.host(host)
+		now = time.Now()
+		at  = h.next
+	)
+	if at.Before(now) {
+		at = now
+	}
+	h.next = at.Add(h.interval())
+	s.mutex.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// host returns the schedule for a host.
+// Note: the mutex should be held when calling host.
+func (s *Scheduler) host(host string) *schedule {
+	h, ok := s.hosts[host]
+	if !ok {
+		h = &schedule{
+			delay: s.delay,
+		}
+		s.hosts[host] = h
+	}
+	return h
+}
+
+// interval returns the amount of time between requests.
+func (s *schedule) interval() time.Duration {
+	if s.throttle > s.delay {
+		return s.throttle
+	}
+	return s.delay
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no delay", func(t *testing.T) {
+		s := NewScheduler(0)
+
+		began := time.Now()
+		for i := 0; i < 100; i++ {
+			if err := s.Wait(context.Background(), "a.com"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if elapsed := time.Since(began); elapsed > time.Second {
+			t.Errorf("expected: < 1s, actual: %s", elapsed)
+		}
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		var (
+			delay = time.Millisecond * 20
+			s     = NewScheduler(delay)
+			ctx   = context.Background()
+		)
+
+		began := time.Now()
+		for i := 0; i < 3; i++ {
+			if err := s.Wait(ctx, "a.com"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if elapsed := time.Since(began); elapsed < delay*2 {
+			t.Errorf("expected: >= %s, actual: %s", delay*2, elapsed)
+		}
+	})
+
+	t.Run("minimum delay", func(t *testing.T) {
+		var (
+			delay = time.Second
+			s     = NewScheduler(delay)
+		)
+		s.Delay("a.com", time.Millisecond)
+
+		s.Wait(context.Background(), "a.com")
+		if next := s.Next("a.com"); time.Until(next) < delay/2 {
+			t.Errorf("expected: %s, actual: %s", delay, time.Until(next))
+		}
+	})
+
+	t.Run("other hosts", func(t *testing.T) {
+		s := NewScheduler(0)
+		s.Delay("a.com", time.Hour)
+
+		ctx := context.Background()
+		s.Wait(ctx, "a.com")
+
+		done := make(chan error)
+		go func() { done <- s.Wait(ctx, "b.com") }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Error("expected other hosts not to be delayed")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		s := NewScheduler(time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.Wait(ctx, "a.com")
+
+		time.AfterFunc(time.Millisecond*10, cancel)
+
+		if err := s.Wait(ctx, "a.com"); err == nil {
+			t.Error("expected wait to be cancelled")
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		var (
+			s     = NewScheduler(0)
+			until = time.Now().Add(time.Hour)
+		)
+		s.Backoff("a.com", until)
+		s.Backoff("a.com", time.Now())
+
+		if expected, actual := until, s.Next("a.com"); !expected.Equal(actual) {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+	})
+
+	t.Run("throttle", func(t *testing.T) {
+		var (
+			delay = time.Second
+			s     = NewScheduler(0)
+		)
+		s.Throttle("a.com", delay)
+
+		s.Wait(context.Background(), "a.com")
+		if next := s.Next("a.com"); time.Until(next) < delay/2 {
+			t.Errorf("expected: %s, actual: %s", delay, time.Until(next))
+		}
+	})
+}
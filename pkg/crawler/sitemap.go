@@ -0,0 +1,207 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/SimonRichardson/crwlr/pkg/report"
+	"github.com/SimonRichardson/crwlr/pkg/sitemap"
+	"github.com/go-kit/kit/log/level"
+)
+
+// maxSitemapDepth is how many sitemap indexes are followed to find a sitemap.
+// A sitemapindex should only reference sitemaps, but some sites nest them.
+const maxSitemapDepth = 2
+
+// Sitemap adds a sitemap to read before the crawl, the urls of the sitemap are
+// crawled along with the seed urls. When the robots.txt is requested, the
+// sitemaps of the robots.txt of the seed urls are read as well.
+// Note: Sitemap should be called before Run.
+func (c *Crawler) Sitemap(u *url.URL) {
+	c.sitemaps = append(c.sitemaps, u)
+}
+
+// readSitemaps reads all the sitemaps of the crawl, pushing the urls of the
+// sitemaps that haven't already been crawled.
+func (c *Crawler) readSitemaps(ctx context.Context, seeds []*url.URL) {
+	sitemaps := append([]*url.URL{}, c.sitemaps...)
+	if c.robotsRequest {
+		for _, u := range seeds {
+			metric := c.getRobots(ctx, u)
+			if metric.Robots == nil {
+				continue
+			}
+			for _, v := range metric.Robots.Sitemaps {
+				if s, err := url.Parse(v); err == nil {
+					sitemaps = append(sitemaps, u.ResolveReference(s))
+				}
+			}
+		}
+	}
+
+	var (
+		seen = map[string]struct{}{}
+		read = map[string]struct{}{}
+	)
+	for _, u := range seeds {
+		seen[u.String()] = struct{}{}
+	}
+
+	var f func(*url.URL, int)
+	f = func(u *url.URL, depth int) {
+		if _, ok := read[u.String()]; ok || depth > maxSitemapDepth || ctx.Err() != nil {
+			return
+		}
+		read[u.String()] = struct{}{}
+
+		s, err := c.requestSitemap(ctx, u)
+		if err != nil {
+			level.Warn(c.logger).Log("sitemap", u.String(), "err", err)
+			return
+		}
+
+		for _, v := range s.Sitemaps {
+			if n, err := url.Parse(v); err == nil {
+				f(u.ResolveReference(n), depth+1)
+			}
+		}
+		for _, v := range s.URLs {
+			n, err := url.Parse(v.Loc)
+			if err != nil || !n.IsAbs() {
+				continue
+			}
+			if n = c.canonicalizer.Canonicalize(n); !c.filtered(n) {
+				continue
+			}
+
+			str := n.String()
+			c.mutex.Lock()
+			c.sitemapURLs[str] = struct{}{}
+			c.mutex.Unlock()
+
+			if _, ok := seen[str]; ok || c.cache.Exists(str) {
+				continue
+			}
+			seen[str] = struct{}{}
+
+			c.push(Item{
+				URL:      n,
+				Priority: v.Priority,
+				LastMod:  v.LastMod,
+			})
+		}
+	}
+	for _, u := range sitemaps {
+		f(u, 0)
+	}
+}
+
+// requestSitemap requests and parses a sitemap.
+func (c *Crawler) requestSitemap(ctx context.Context, u *url.URL) (*sitemap.Sitemap, error) {
+	var (
+		began  = time.Now()
+		metric = NewMetric()
+		res    *sitemap.Sitemap
+	)
+
+	body, err := c.request(ctx, u, peer.Robot, checkResponseStatus)
+	if err == nil {
+		res, err = sitemap.Parse(bytes.NewReader(body))
+	}
+
+	if err != nil {
+		metric.Errorred.Increment()
+	} else {
+		metric.Received.Increment()
+	}
+	metric.Requested.Increment()
+	metric.SetDuration(time.Since(began))
+
+	c.cache.Set(u.String(), metric)
+
+	return res, err
+}
+
+// CoverageReport returns the report of the urls in the sitemaps that can't be
+// reached by links and the pages that aren't in the sitemaps.
+func (c *Crawler) CoverageReport() *report.CoverageReport {
+	c.mutex.Lock()
+	var (
+		sitemap = setToStrings(c.sitemapURLs)
+		seeds   = append([]string{}, c.seeds...)
+	)
+	c.mutex.Unlock()
+
+	r, err := NewCoverageReport(c.cache, sitemap, seeds)
+	if err != nil {
+		level.Warn(c.logger).Log("report", "coverage", "err", err)
+	}
+	return r
+}
+
+// NewCoverageReport returns the report comparing the urls of the sitemaps
+// against the pages with in a cache that can be reached by links from the
+// seed urls.
+func NewCoverageReport(cache Cache, sitemap, seeds []string) (*report.CoverageReport, error) {
+	var (
+		linked  = append([]string{}, seeds...)
+		indexed []string
+	)
+	err := cache.Range(func(k string, v *Metric) bool {
+		linked = append(linked, v.RefLinks...)
+		if v.Received.Time() > 0 && v.Robots == nil && !v.NoIndex {
+			indexed = append(indexed, k)
+		}
+		return true
+	})
+
+	return report.NewCoverageReport(sitemap, linked, indexed), err
+}
+
+// SitemapXML returns the sitemap.xml of the pages that have been crawled.
+func (c *Crawler) SitemapXML() *report.SitemapXML {
+	r, err := NewSitemapXML(c.cache)
+	if err != nil {
+		level.Warn(c.logger).Log("report", "sitemap-xml", "err", err)
+	}
+	return r
+}
+
+// NewSitemapXML returns the sitemap.xml of the html pages with in a cache
+// that were received. Pages that asked not to be indexed, or that have a
+// different canonical url are left out.
+func NewSitemapXML(cache Cache) (*report.SitemapXML, error) {
+	var urls []report.SitemapURL
+	err := cache.Range(func(k string, v *Metric) bool {
+		if v.Received.Time() > 0 && isHTML(v.ContentType) && !v.NoIndex &&
+			(v.Canonical == "" || v.Canonical == k) {
+			urls = append(urls, report.SitemapURL{
+				Loc:     k,
+				LastMod: v.LastModified,
+			})
+		}
+		return true
+	})
+
+	return report.NewSitemapXML(urls), err
+}
+
+// isHTML returns if the content type is that of a html page.
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+func setToStrings(a map[string]struct{}) []string {
+	res := make([]string, 0, len(a))
+	for k := range a {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
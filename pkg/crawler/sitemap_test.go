@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/go-kit/kit/log"
+)
+
+func TestCrawl_Sitemap(t *testing.T) {
+	t.Parallel()
+
+	var site *httptest.Server
+	site = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nSitemap: /sitemap_index.xml\n")
+		case "/sitemap_index.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/sitemap.xml.gz</loc></sitemap></sitemapindex>`, site.URL)
+		case "/sitemap.xml.gz":
+			gz := gzip.NewWriter(w)
+			fmt.Fprintf(gz, `<urlset>
+<url><loc>%[1]s</loc><priority>1.0</priority></url>
+<url><loc>%[1]s/linked</loc></url>
+<url><loc>%[1]s/orphan</loc><lastmod>2005-01-01</lastmod></url>
+<url><loc>http://other.com/</loc></url>
+</urlset>`, site.URL)
+			gz.Close()
+		case "/":
+			fmt.Fprint(w, `<a href="/linked">linked</a><a href="/unlisted">unlisted</a>`)
+		default:
+			fmt.Fprint(w, `<html></html>`)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := func(robots bool, sitemaps ...string) *Crawler {
+		c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), robots, false, 2, 2, log.NewNopLogger())
+		c.Filter(Addr(u))
+		for _, v := range sitemaps {
+			s, err := url.Parse(fmt.Sprintf("%s%s", u.String(), v))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Sitemap(s)
+		}
+
+		if err := c.Run(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	for _, v := range []struct {
+		name     string
+		robots   bool
+		sitemaps []string
+	}{
+		{"robots", true, nil},
+		{"flag", false, []string{"/sitemap.xml.gz"}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			c := fn(v.robots, v.sitemaps...)
+
+			m, err := c.cache.Get(fmt.Sprintf("%s/orphan", u.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected, actual := int64(1), m.Received.Time(); expected != actual {
+				t.Errorf("expected: %d, actual: %d", expected, actual)
+			}
+
+			// The seed is also in the sitemap, but should only be requested once.
+			m, err = c.cache.Get(u.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected, actual := int64(1), m.Requested.Time(); expected != actual {
+				t.Errorf("expected: %d, actual: %d", expected, actual)
+			}
+
+			r := c.CoverageReport()
+			if expected, actual := []string{fmt.Sprintf("%s/orphan", u.String())}, r.Unlinked(); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+			if expected, actual := []string{fmt.Sprintf("%s/unlisted", u.String())}, r.Unlisted(); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestCrawl_SitemapXML(t *testing.T) {
+	t.Parallel()
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Last-Modified", "Sat, 01 Jan 2005 12:00:00 GMT")
+			fmt.Fprint(w, `<a href="/noindex">a</a><a href="/duplicate">b</a><a href="/image.png">c</a><a href="/bad">d</a><a href="/self">e</a>`)
+		case "/noindex":
+			fmt.Fprint(w, `<meta name="robots" content="noindex">`)
+		case "/duplicate":
+			fmt.Fprint(w, `<link rel="canonical" href="/">`)
+		case "/self":
+			fmt.Fprint(w, `<link rel="canonical" href="/self">`)
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCrawler(http.DefaultClient, peer.NewUserAgent("", ""), false, false, 2, 2, log.NewNopLogger())
+	c.Filter(Addr(u))
+	if err := c.Run(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SitemapXML().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>%[1]s</loc>
+    <lastmod>2005-01-01T12:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>%[1]s/self</loc>
+  </url>
+</urlset>
+`, u.String())
+	if actual := buf.String(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
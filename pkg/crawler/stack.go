@@ -0,0 +1,164 @@
+package crawler
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Item is a url waiting to be crawled, along with how many hops (depth) it is
+// away from the seed url. Items read from a sitemap also have the priority and
+// last modified time of the sitemap.
+type Item struct {
+	URL      *url.URL
+	Depth    int
+	Priority float64
+	LastMod  time.Time
+}
+
+// stack guards a Frontier so that it can be shared between workers. Unlike a
+// channel it's unbounded, so workers that discover links never block when
+// pushing them.
+// Items for a host that can't be requested yet are parked, so they don't hold
+// up the items for other hosts.
+type stack struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	frontier Frontier
+	inflight map[*url.URL]Item
+	parked   map[string][]Item
+	hosts    []string
+	closed   bool
+}
+
+func newStack(frontier Frontier) *stack {
+	s := &stack{
+		frontier: frontier,
+		inflight: map[*url.URL]Item{},
+		parked:   map[string][]Item{},
+	}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// Push adds an item to the stack, it returns false if the stack has been
+// closed.
+func (s *stack) Push(i Item) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.frontier.Push(i)
+	s.cond.Signal()
+	return true
+}
+
+// Pop blocks until an item is available or the stack is closed. If the stack
+// is closed it returns false.
+// The item is considered in-flight until Done is called for it.
+func (s *stack) Pop() (Item, bool) {
+	return s.PopFor(nil)
+}
+
+// PopFor blocks until an item is available for a host that can be acquired or
+// the stack is closed. Items for hosts that can't be acquired are parked until
+// an item is marked as Done, so other hosts can be requested in the meantime.
+// A nil acquire acquires every host.
+// The item is considered in-flight until Done is called for it.
+func (s *stack) PopFor(acquire func(host string) bool) (Item, bool) {
+	if acquire == nil {
+		acquire = func(string) bool { return true }
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for {
+		if s.closed {
+			return Item{}, false
+		}
+
+		// Parked items were popped from the frontier first, so they're handed
+		// out before any of the items still in the frontier.
+		for k, host := range s.hosts {
+			if !acquire(host) {
+				continue
+			}
+
+			items := s.parked[host]
+			i := items[0]
+			if len(items) == 1 {
+				delete(s.parked, host)
+				s.hosts = append(s.hosts[:k], s.hosts[k+1:]...)
+			} else {
+				s.parked[host] = items[1:]
+			}
+			s.inflight[i.URL] = i
+			return i, true
+		}
+
+		for s.frontier.Len() > 0 {
+			i, _ := s.frontier.Pop()
+			host := i.URL.Host
+			if _, ok := s.parked[host]; !ok && acquire(host) {
+				s.inflight[i.URL] = i
+				return i, true
+			}
+			s.park(i)
+		}
+
+		s.cond.Wait()
+	}
+}
+
+// park holds on to an item until its host can be acquired.
+// Note: the mutex should be held when calling park.
+func (s *stack) park(i Item) {
+	host := i.URL.Host
+	if _, ok := s.parked[host]; !ok {
+		s.hosts = append(s.hosts, host)
+	}
+	s.parked[host] = append(s.parked[host], i)
+}
+
+// Done marks an in-flight item as completed, waking up any blocked PopFor
+// calls as the host of the item may now be acquired.
+func (s *stack) Done(i Item) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.inflight, i.URL)
+	if len(s.hosts) > 0 {
+		s.cond.Broadcast()
+	}
+}
+
+// Snapshot calls fn with all the items that are still to be completed, either
+// waiting in the frontier, parked or in-flight. No items can be pushed, popped or
+// completed until fn returns.
+func (s *stack) Snapshot(fn func(pending, inflight []Item)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	inflight := make([]Item, 0, len(s.inflight))
+	for _, v := range s.inflight {
+		inflight = append(inflight, v)
+	}
+	var pending []Item
+	for _, host := range s.hosts {
+		pending = append(pending, s.parked[host]...)
+	}
+	fn(append(pending, s.frontier.Items()...), inflight)
+}
+
+// Close wakes up any blocked Pop calls. It's safe to call Close multiple times.
+func (s *stack) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	s.cond.Broadcast()
+}
@@ -0,0 +1,162 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/test"
+)
+
+func TestStack(t *testing.T) {
+	t.Parallel()
+
+	t.Run("push pop", func(t *testing.T) {
+		fn := func(a test.ASCII) bool {
+			u, err := url.Parse(fmt.Sprintf("http://%s.com", a.String()))
+			if err != nil {
+				t.Error(err)
+				return false
+			}
+
+			s := newStack(NewFIFO())
+			s.Push(Item{URL: u, Depth: 1})
+
+			v, ok := s.Pop()
+			return ok && v.URL.String() == u.String() && v.Depth == 1
+		}
+
+		if err := quick.Check(fn, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		s := newStack(NewFIFO())
+
+		done := make(chan bool)
+		go func() {
+			_, ok := s.Pop()
+			done <- ok
+		}()
+
+		s.Close()
+
+		select {
+		case ok := <-done:
+			if ok {
+				t.Error("expected pop to fail once closed")
+			}
+		case <-time.After(time.Second):
+			t.Error("expected pop to return once closed")
+		}
+	})
+
+	t.Run("pop for", func(t *testing.T) {
+		var (
+			a1, _ = url.Parse("http://a.com/1")
+			a2, _ = url.Parse("http://a.com/2")
+			b1, _ = url.Parse("http://b.com/1")
+		)
+
+		s := newStack(NewFIFO())
+		for _, u := range []*url.URL{a1, a2, b1} {
+			s.Push(Item{URL: u})
+		}
+
+		saturated := map[string]bool{"a.com": true}
+		acquire := func(host string) bool {
+			return !saturated[host]
+		}
+
+		v, ok := s.PopFor(acquire)
+		if !ok {
+			t.Fatal("expected pop")
+		}
+		if expected, actual := b1.String(), v.URL.String(); expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+
+		// Parked items are still pending.
+		var pending int
+		s.Snapshot(func(p, _ []Item) {
+			pending = len(p)
+		})
+		if expected, actual := 2, pending; expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+
+		done := make(chan Item)
+		go func() {
+			v, _ := s.PopFor(acquire)
+			done <- v
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("expected pop to block whilst the host is saturated")
+		case <-time.After(time.Millisecond * 10):
+		}
+
+		s.mutex.Lock()
+		saturated["a.com"] = false
+		s.mutex.Unlock()
+		s.Done(v)
+
+		select {
+		case v := <-done:
+			if expected, actual := a1.String(), v.URL.String(); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		case <-time.After(time.Second):
+			t.Error("expected pop once the host is free")
+		}
+	})
+
+	t.Run("push closed", func(t *testing.T) {
+		u, _ := url.Parse("http://a.com")
+
+		s := newStack(NewFIFO())
+		s.Close()
+
+		if s.Push(Item{URL: u}) {
+			t.Error("expected push to fail once closed")
+		}
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		a, _ := url.Parse("http://a.com")
+		b, _ := url.Parse("http://b.com")
+
+		s := newStack(NewFIFO())
+		s.Push(Item{URL: a})
+		s.Push(Item{URL: b})
+
+		i, _ := s.Pop()
+
+		var pending, inflight []Item
+		snapshot := func(p, f []Item) {
+			pending, inflight = p, f
+		}
+
+		s.Snapshot(snapshot)
+		if expected, actual := 1, len(pending); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := 1, len(inflight); expected != actual {
+			t.Fatalf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := a.String(), inflight[0].URL.String(); expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+
+		s.Done(i)
+
+		s.Snapshot(snapshot)
+		if expected, actual := 0, len(inflight); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+}
@@ -0,0 +1,308 @@
+package crawler
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/SimonRichardson/crwlr/pkg/document"
+	"github.com/pkg/errors"
+)
+
+// State is a checkpoint of a crawl, which can be used to resume the crawl at a
+// later date.
+type State struct {
+	// Pending are the urls that are still to be crawled.
+	Pending []StateItem `json:"pending"`
+	// InFlight are the pending urls that were being crawled at the time of
+	// the checkpoint.
+	InFlight []string `json:"inflight,omitempty"`
+	// Metrics are the metrics of the urls that have already been crawled.
+	// Metrics are only held when the cache isn't persistent.
+	Metrics map[string]StateMetric `json:"metrics"`
+	// Cache is the path of the disk cache that holds the metrics of the crawl.
+	Cache string `json:"cache,omitempty"`
+	// Generation is the generation of the urls that were claimed before the
+	// checkpoint.
+	Generation int64 `json:"generation,omitempty"`
+	// Budget is how much of the budget had been used.
+	Budget StateBudget `json:"budget"`
+	// Seeds are the urls the crawl was started from.
+	Seeds []string `json:"seeds,omitempty"`
+	// Sitemap are the urls that were read from the sitemaps of the crawl.
+	Sitemap []string `json:"sitemap,omitempty"`
+}
+
+// StateItem is a serializable Item.
+type StateItem struct {
+	URL      string    `json:"url"`
+	Depth    int       `json:"depth"`
+	Priority float64   `json:"priority,omitempty"`
+	LastMod  time.Time `json:"lastmod,omitempty"`
+}
+
+// StateBudget is the serializable usage of a budget.
+type StateBudget struct {
+	Pages   int64         `json:"pages"`
+	Bytes   int64         `json:"bytes"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// StateMetric is a serializable Metric.
+// Note: robots data isn't serialized, instead it's requested again on resume.
+type StateMetric struct {
+	Requested     int64                    `json:"requested"`
+	Received      int64                    `json:"received"`
+	Filtered      int64                    `json:"filtered"`
+	Errorred      int64                    `json:"errorred"`
+	Skipped       int64                    `json:"skipped"`
+	Retried       int64                    `json:"retried"`
+	Duration      time.Duration            `json:"duration"`
+	RefLinks      []string                 `json:"ref_links"`
+	RefAssetLinks []string                 `json:"ref_asset_links"`
+	RefExternal   []string                 `json:"ref_external_links,omitempty"`
+	RefRejected   []string                 `json:"ref_rejected_links,omitempty"`
+	RefNofollow   []string                 `json:"ref_nofollow_links,omitempty"`
+	RefKinds      map[string]document.Kind `json:"ref_kinds,omitempty"`
+	NoIndex       bool                     `json:"noindex,omitempty"`
+	StatusCode    int                      `json:"status_code,omitempty"`
+	ContentLength int64                    `json:"content_length,omitempty"`
+	Headers       map[string]string        `json:"headers,omitempty"`
+	Redirects     []Redirect               `json:"redirects,omitempty"`
+	ContentType   string                   `json:"content_type,omitempty"`
+	Canonical     string                   `json:"canonical,omitempty"`
+	LastModified  time.Time                `json:"last_modified,omitempty"`
+	Latencies     []Latency                `json:"latencies,omitempty"`
+	Generation    int64                    `json:"generation,omitempty"`
+}
+
+// Checkpoint writes the current State of the crawl to the writer. It's safe to
+// call Checkpoint whilst the crawl is running.
+// When the cache is a DiskCache, the metrics are left in the cache and the
+// state only refers to it, so the cost of a checkpoint doesn't grow with the
+// cache.
+func (c *Crawler) Checkpoint(w io.Writer) error {
+	state := State{
+		Pending: []StateItem{},
+		Metrics: map[string]StateMetric{},
+	}
+	disk, persistent := c.cache.(*DiskCache)
+
+	// Take a snapshot of the stack along with the cache, so nothing can move
+	// between the two whilst the checkpoint is taken.
+	var err error
+	c.stack.Snapshot(func(pending, inflight []Item) {
+		// Anything that's in-flight hasn't completed, so it's metrics can't be
+		// trusted and it has to be crawled again.
+		incomplete := map[string]struct{}{}
+		for _, v := range inflight {
+			incomplete[v.URL.String()] = struct{}{}
+			state.InFlight = append(state.InFlight, v.URL.String())
+		}
+
+		for _, v := range append(inflight, pending...) {
+			state.Pending = append(state.Pending, StateItem{
+				URL:      v.URL.String(),
+				Depth:    v.Depth,
+				Priority: v.Priority,
+				LastMod:  v.LastMod,
+			})
+		}
+
+		// Urls claimed from now on belong to the next generation, so they can
+		// be told apart from the urls the checkpoint covers.
+		state.Generation = atomic.AddInt64(&c.generation, 1) - 1
+		if persistent {
+			return
+		}
+
+		err = c.cache.Range(func(k string, v *Metric) bool {
+			if _, ok := incomplete[k]; !ok && v.Robots == nil {
+				state.Metrics[k] = NewStateMetric(v)
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to read cache")
+	}
+
+	if persistent {
+		// Every metric the checkpoint covers has to be on disk.
+		if err := disk.Sync(); err != nil {
+			return err
+		}
+		state.Cache = disk.Path()
+	}
+
+	pages, bytes, elapsed := c.budget.Used()
+	state.Budget = StateBudget{
+		Pages:   pages,
+		Bytes:   bytes,
+		Elapsed: elapsed,
+	}
+
+	c.mutex.Lock()
+	state.Seeds = append(state.Seeds, c.seeds...)
+	state.Sitemap = setToStrings(c.sitemapURLs)
+	c.mutex.Unlock()
+
+	return json.NewEncoder(w).Encode(state)
+}
+
+// Resume reads a State from the reader, populating the cache and the stack so
+// that the crawl can continue where it left off. If the state refers to a
+// DiskCache, the cache has to be the same DiskCache, which is used as is,
+// other than dropping the urls the checkpoint doesn't cover.
+// Note: Resume should be called after Frontier, Cache and Limit and before Run.
+func (c *Crawler) Resume(r io.Reader) error {
+	var state State
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return errors.Wrap(err, "invalid state")
+	}
+
+	if state.Cache != "" {
+		if err := c.resumeCache(state); err != nil {
+			return err
+		}
+	} else {
+		for k, v := range state.Metrics {
+			c.cache.Set(k, v.Metric())
+		}
+	}
+	c.budget.Restore(state.Budget.Pages, state.Budget.Bytes, state.Budget.Elapsed)
+
+	for _, v := range state.Pending {
+		u, err := url.Parse(v.URL)
+		if err != nil {
+			return errors.Wrap(err, "invalid state url")
+		}
+		c.push(Item{
+			URL:      u,
+			Depth:    v.Depth,
+			Priority: v.Priority,
+			LastMod:  v.LastMod,
+		})
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.seeds = append(c.seeds, state.Seeds...)
+	for _, v := range state.Sitemap {
+		c.sitemapURLs[v] = struct{}{}
+	}
+	return nil
+}
+
+// resumeCache drops the urls that were in-flight at the time of the checkpoint
+// or claimed after it from the DiskCache the state refers to, as the urls they
+// lead to aren't pending, so they have to be crawled again.
+func (c *Crawler) resumeCache(state State) error {
+	disk, ok := c.cache.(*DiskCache)
+	if !ok || disk.Path() != state.Cache {
+		return errors.Errorf("state expects the cache at %s", state.Cache)
+	}
+
+	stale := append([]string{}, state.InFlight...)
+	err := disk.Range(func(k string, v *Metric) bool {
+		if v.Generation > state.Generation {
+			stale = append(stale, k)
+		}
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to read cache")
+	}
+	for _, v := range stale {
+		disk.Delete(v)
+	}
+
+	atomic.StoreInt64(&c.generation, state.Generation+1)
+	return nil
+}
+
+// NewStateMetric creates a serializable StateMetric from a Metric.
+func NewStateMetric(m *Metric) StateMetric {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	kinds := make(map[string]document.Kind, len(m.RefKinds))
+	for k, v := range m.RefKinds {
+		kinds[k] = v
+	}
+
+	return StateMetric{
+		Requested:     m.Requested.Time(),
+		Received:      m.Received.Time(),
+		Filtered:      m.Filtered.Time(),
+		Errorred:      m.Errorred.Time(),
+		Skipped:       m.Skipped.Time(),
+		Retried:       m.Retried.Time(),
+		Duration:      m.Duration,
+		RefLinks:      append([]string{}, m.RefLinks...),
+		RefAssetLinks: append([]string{}, m.RefAssetLinks...),
+		RefExternal:   append([]string{}, m.RefExternalLinks...),
+		RefRejected:   append([]string{}, m.RefRejectedLinks...),
+		RefNofollow:   append([]string{}, m.RefNofollowLinks...),
+		RefKinds:      kinds,
+		NoIndex:       m.NoIndex,
+		StatusCode:    m.StatusCode,
+		ContentLength: m.ContentLength,
+		Headers:       copyHeaders(m.Headers),
+		Redirects:     append([]Redirect{}, m.Redirects...),
+		ContentType:   m.ContentType,
+		Canonical:     m.Canonical,
+		LastModified:  m.LastModified,
+		Latencies:     append([]Latency{}, m.Latencies...),
+		Generation:    m.Generation,
+	}
+}
+
+// Metric creates a Metric from the StateMetric.
+func (s StateMetric) Metric() *Metric {
+	m := NewMetric()
+	m.Requested = &Clock{s.Requested}
+	m.Received = &Clock{s.Received}
+	m.Filtered = &Clock{s.Filtered}
+	m.Errorred = &Clock{s.Errorred}
+	m.Skipped = &Clock{s.Skipped}
+	m.Retried = &Clock{s.Retried}
+	m.Duration = s.Duration
+	m.NoIndex = s.NoIndex
+	m.StatusCode = s.StatusCode
+	m.ContentLength = s.ContentLength
+	m.Headers = s.Headers
+	m.Redirects = s.Redirects
+	m.ContentType = s.ContentType
+	m.Canonical = s.Canonical
+	m.LastModified = s.LastModified
+	m.Latencies = s.Latencies
+	m.RefExternalLinks = s.RefExternal
+	m.RefRejectedLinks = s.RefRejected
+	m.RefNofollowLinks = s.RefNofollow
+	m.Generation = s.Generation
+	if s.RefLinks != nil {
+		m.RefLinks = s.RefLinks
+	}
+	if s.RefAssetLinks != nil {
+		m.RefAssetLinks = s.RefAssetLinks
+	}
+	if s.RefKinds != nil {
+		m.RefKinds = s.RefKinds
+	}
+	return m
+}
+
+func copyHeaders(a map[string]string) map[string]string {
+	if a == nil {
+		return nil
+	}
+	res := make(map[string]string, len(a))
+	for k, v := range a {
+		res[k] = v
+	}
+	return res
+}
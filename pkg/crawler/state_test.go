@@ -0,0 +1,212 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/SimonRichardson/crwlr/pkg/peer"
+	"github.com/SimonRichardson/crwlr/pkg/static"
+	"github.com/go-kit/kit/log"
+)
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	var (
+		client = http.DefaultClient
+		agent  = peer.NewUserAgent("", "")
+		logger = log.NewNopLogger()
+	)
+
+	t.Run("in-flight", func(t *testing.T) {
+		a, _ := url.Parse("http://a.com")
+		b, _ := url.Parse("http://b.com")
+
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.push(Item{URL: a})
+		c.push(Item{URL: b, Depth: 1})
+
+		// Pop a, which is then in-flight with a partial metric.
+		if _, ok := c.stack.Pop(); !ok {
+			t.Fatal("expected pop")
+		}
+		c.cache.Set(a.String(), NewMetric())
+
+		var buf bytes.Buffer
+		if err := c.Checkpoint(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewCrawler(client, agent, false, false, 1, 1, logger)
+		if err := r.Resume(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if r.cache.Exists(a.String()) {
+			t.Error("expected in-flight metric to be excluded")
+		}
+		if expected, actual := int64(2), r.gauge.Value(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		for _, v := range []Item{{URL: a}, {URL: b, Depth: 1}} {
+			i, ok := r.stack.Pop()
+			if !ok {
+				t.Fatal("expected pop")
+			}
+			if expected, actual := v.URL.String(), i.URL.String(); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+			if expected, actual := v.Depth, i.Depth; expected != actual {
+				t.Errorf("expected: %d, actual: %d", expected, actual)
+			}
+		}
+	})
+
+	t.Run("disk cache", func(t *testing.T) {
+		a, _ := url.Parse("http://a.com")
+		b, _ := url.Parse("http://b.com")
+		x, _ := url.Parse("http://x.com")
+		y, _ := url.Parse("http://y.com")
+
+		cache, path, cleanup := newDiskCache(t)
+		defer cleanup()
+
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		c.Cache(cache)
+		c.Limit(Limits{MaxPages: 10})
+
+		// x has been crawled, a is in-flight and b is pending.
+		c.claim(x)
+		c.push(Item{URL: a})
+		c.push(Item{URL: b, Depth: 1})
+		if _, ok := c.stack.Pop(); !ok {
+			t.Fatal("expected pop")
+		}
+		c.claim(a)
+
+		var buf bytes.Buffer
+		if err := c.Checkpoint(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		// y is claimed after the checkpoint.
+		c.claim(y)
+
+		var state State
+		if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := 0, len(state.Metrics); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if expected, actual := path, state.Cache; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+
+		if err := cache.Close(); err != nil {
+			t.Fatal(err)
+		}
+		reopened, err := NewDiskCache(path, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reopened.Close()
+
+		r := NewCrawler(client, agent, false, false, 1, 1, logger)
+		r.Cache(reopened)
+		r.Limit(Limits{MaxPages: 10})
+		if err := r.Resume(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if !r.cache.Exists(x.String()) {
+			t.Error("expected crawled metric to be kept")
+		}
+		if r.cache.Exists(a.String()) {
+			t.Error("expected in-flight metric to be dropped")
+		}
+		if r.cache.Exists(y.String()) {
+			t.Error("expected metric claimed after the checkpoint to be dropped")
+		}
+		if pages, _, _ := r.budget.Used(); pages != 2 {
+			t.Errorf("expected: %d, actual: %d", 2, pages)
+		}
+		if expected, actual := int64(2), r.gauge.Value(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("disk cache mismatch", func(t *testing.T) {
+		var buf bytes.Buffer
+		json.NewEncoder(&buf).Encode(State{Cache: "/tmp/crwlr-missing"})
+
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		if err := c.Resume(&buf); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		if err := c.Resume(strings.NewReader("{")); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		server := httptest.NewServer(static.NewAPI(false, logger))
+		defer server.Close()
+
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Cancel the crawl as soon as the seed has been crawled, leaving the
+		// rest of the site pending.
+		c := NewCrawler(client, agent, false, false, 1, 1, logger)
+		ctx, cancel := context.WithCancel(context.Background())
+		c.Filter(Addr(u))
+		c.Filter(Func(func(v *url.URL) bool {
+			if v.String() != u.String() {
+				cancel()
+			}
+			return true
+		}))
+		if err := c.Run(ctx, u); err == nil {
+			t.Fatal("expected crawl to be cancelled")
+		}
+
+		var buf bytes.Buffer
+		if err := c.Checkpoint(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewCrawler(client, agent, false, false, 1, 1, logger)
+		r.Filter(Addr(u))
+		if err := r.Resume(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := r.cache.Get(u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := int64(1), m.Received.Time(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+		if !r.cache.Exists(fmt.Sprintf("%s/page2", u.String())) {
+			t.Error("expected to have crawled page2")
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// throttleWeight is how much weight a new observation has against the
+	// previous observations.
+	throttleWeight = 0.3
+	// throttleStep is the smallest delay the Throttle will slow down to.
+	throttleStep = 100 * time.Millisecond
+)
+
+// Throttle adapts the delay between requests to a host, depending on how the
+// host is responding. If the latency or the error rate of a host climbs above
+// the thresholds, the delay is doubled (up to a maximum delay) and once the host
+// recovers the delay is halved until there is no delay at all.
+type Throttle struct {
+	mutex     sync.Mutex
+	latency   time.Duration
+	errorRate float64
+	maxDelay  time.Duration
+	hosts     map[string]*health
+}
+
+type health struct {
+	latency float64
+	errors  float64
+	delay   time.Duration
+}
+
+// NewThrottle creates a Throttle with the latency and errorRate thresholds and
+// the maximum delay it will slow a host down to.
+func NewThrottle(latency time.Duration, errorRate float64, maxDelay time.Duration) *Throttle {
+	return &Throttle{
+		latency:   latency,
+		errorRate: errorRate,
+		maxDelay:  maxDelay,
+		hosts:     map[string]*health{},
+	}
+}
+
+// Observe records how long a request to a host took and if it failed due to
+// the host being overloaded. It returns the delay that should now be used
+// between requests to the host.
+func (t *Throttle) Observe(host string, latency time.Duration, overloaded bool) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	h, ok := t.hosts[host]
+	if !ok {
+		h = &health{
+			latency: float64(latency),
+		}
+		t.hosts[host] = h
+	}
+
+	var failure float64
+	if overloaded {
+		failure = 1
+	}
+	h.latency = (throttleWeight * float64(latency)) + ((1 - throttleWeight) * h.latency)
+	h.errors = (throttleWeight * failure) + ((1 - throttleWeight) * h.errors)
+
+	if (t.latency > 0 && time.Duration(h.latency) > t.latency) || h.errors > t.errorRate {
+		// Slow down
+		if h.delay *= 2; h.delay < throttleStep {
+			h.delay = throttleStep
+		}
+		if t.maxDelay > 0 && h.delay > t.maxDelay {
+			h.delay = t.maxDelay
+		}
+	} else {
+		// Speed up
+		if h.delay /= 2; h.delay < throttleStep {
+			h.delay = 0
+		}
+	}
+
+	return h.delay
+}
+
+// overloaded returns if the error describes a host that's struggling to
+// respond, either because of a network failure or the host saying so.
+func overloaded(err error) bool {
+	if err == nil {
+		return false
+	}
+	if e, ok := errors.Cause(err).(*StatusError); ok {
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	}
+	return true
+}
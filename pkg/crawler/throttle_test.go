@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestThrottle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("healthy", func(t *testing.T) {
+		throttle := NewThrottle(time.Second, 0.5, time.Minute)
+		for i := 0; i < 10; i++ {
+			if delay := throttle.Observe("a.com", time.Millisecond, false); delay != 0 {
+				t.Errorf("expected: 0, actual: %s", delay)
+			}
+		}
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		throttle := NewThrottle(time.Second, 0.5, time.Minute)
+
+		var delay time.Duration
+		for i := 0; i < 3; i++ {
+			delay = throttle.Observe("a.com", time.Second*2, false)
+		}
+		if expected := throttleStep * 4; delay != expected {
+			t.Errorf("expected: %s, actual: %s", expected, delay)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		throttle := NewThrottle(0, 0.5, time.Minute)
+
+		var delay time.Duration
+		for i := 0; i < 3; i++ {
+			delay = throttle.Observe("a.com", time.Millisecond, true)
+		}
+		if delay == 0 {
+			t.Error("expected host to be slowed down")
+		}
+	})
+
+	t.Run("max delay", func(t *testing.T) {
+		throttle := NewThrottle(time.Second, 0.5, time.Second)
+
+		var delay time.Duration
+		for i := 0; i < 100; i++ {
+			delay = throttle.Observe("a.com", time.Second*2, true)
+		}
+		if expected := time.Second; delay != expected {
+			t.Errorf("expected: %s, actual: %s", expected, delay)
+		}
+	})
+
+	t.Run("recover", func(t *testing.T) {
+		throttle := NewThrottle(time.Second, 0.5, time.Minute)
+		for i := 0; i < 5; i++ {
+			throttle.Observe("a.com", time.Second*2, true)
+		}
+
+		var delay time.Duration
+		for i := 0; i < 100; i++ {
+			delay = throttle.Observe("a.com", time.Millisecond, false)
+		}
+		if delay != 0 {
+			t.Errorf("expected: 0, actual: %s", delay)
+		}
+	})
+
+	t.Run("other hosts", func(t *testing.T) {
+		throttle := NewThrottle(time.Second, 0.5, time.Minute)
+		for i := 0; i < 5; i++ {
+			throttle.Observe("a.com", time.Second*2, true)
+		}
+
+		if delay := throttle.Observe("b.com", time.Millisecond, false); delay != 0 {
+			t.Errorf("expected: 0, actual: %s", delay)
+		}
+	})
+}
+
+func TestOverloaded(t *testing.T) {
+	t.Parallel()
+
+	for _, testcase := range []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("connection reset"), true},
+		{&StatusError{StatusCode: http.StatusNotFound}, false},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{errors.Wrap(&StatusError{StatusCode: http.StatusBadGateway}, "wrapped"), true},
+	} {
+		if actual := overloaded(testcase.err); testcase.expected != actual {
+			t.Errorf("(%v): expected: %t, actual: %t", testcase.err, testcase.expected, actual)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2017, 5, 24, 0, 0, 0, 0, time.UTC)
+
+	for _, testcase := range []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", time.Minute * 2},
+		{"-1", 0},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	} {
+		resp := &http.Response{Header: http.Header{}}
+		if testcase.header != "" {
+			resp.Header.Set("Retry-After", testcase.header)
+		}
+
+		if actual := retryAfter(resp, now); testcase.expected != actual {
+			t.Errorf("(%q): expected: %s, actual: %s", testcase.header, testcase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package document
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Stylesheet wraps a css stylesheet so that we can extract all the relavent
+// urls from it.
+type Stylesheet struct {
+	url  *url.URL
+	body string
+}
+
+// NewStylesheet creates a new Stylesheet to use.
+func NewStylesheet(url *url.URL, body []byte) *Stylesheet {
+	return &Stylesheet{
+		url:  url,
+		body: string(body),
+	}
+}
+
+// Walk walks through all the url() and @import references of the Stylesheet,
+// along with the Kind of each of them.
+// Note: it will normalize the references to the stylesheets url.
+func (s *Stylesheet) Walk(fn func(*url.URL, Kind) error) error {
+	for _, v := range cssRefs(s.body) {
+		if u, ok := normalizeLink(s.url, v.val); ok && !rejected(u) {
+			if err := fn(u, v.kind); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Styles walks through all the Documents <style> blocks and style attributes,
+// calling the fn with the url() and @import references along with the Kind of
+// each of them.
+// Note: it will normalize the references to the documents url.
+func Styles(fn func(*url.URL, Kind) error) Walker {
+	return func(root *url.URL, node *html.Node) error {
+		var refs []ref
+		if node.DataAtom == atom.Style {
+			for c := node.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.TextNode {
+					refs = append(refs, cssRefs(c.Data)...)
+				}
+			}
+		}
+		for _, v := range attrs(node, "style") {
+			refs = append(refs, cssRefs(v)...)
+		}
+
+		for _, v := range refs {
+			if u, ok := normalizeLink(root, v.val); ok && !rejected(u) {
+				fn(u, v.kind)
+			}
+		}
+		return nil
+	}
+}
+
+// cssRefs returns the raw values of the url() and @import references of the
+// css. Imports are stylesheets, fonts are found by their extension and
+// everything else is expected to be an image.
+func cssRefs(css string) []ref {
+	var (
+		res []ref
+		s   = stripComments(css)
+	)
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '"' || s[i] == '\'':
+			// Skip over strings, so that the contents of them aren't mistaken
+			// for a reference.
+			_, i = cssString(s, i)
+		case hasPrefixFold(s[i:], "@import"):
+			i = skipSpace(s, i+len("@import"))
+			var val string
+			switch {
+			case i < len(s) && (s[i] == '"' || s[i] == '\''):
+				val, i = cssString(s, i)
+			case hasPrefixFold(s[i:], "url("):
+				val, i = cssURL(s, i+len("url("))
+			}
+			if val != "" {
+				res = append(res, ref{val, KindStylesheet})
+			}
+		case hasPrefixFold(s[i:], "url(") && (i == 0 || !isIdent(s[i-1])):
+			var val string
+			if val, i = cssURL(s, i+len("url(")); val != "" {
+				res = append(res, ref{val, cssKind(val)})
+			}
+		default:
+			i++
+		}
+	}
+	return res
+}
+
+// cssKind returns the Kind of a url() reference.
+func cssKind(val string) Kind {
+	if u, err := url.Parse(val); err == nil {
+		switch strings.ToLower(path.Ext(u.Path)) {
+		case ".woff", ".woff2", ".ttf", ".otf", ".eot":
+			return KindFont
+		}
+	}
+	return KindImage
+}
+
+// cssURL reads the value of a url( starting at i, returning the value and the
+// position after the closing bracket.
+func cssURL(s string, i int) (string, int) {
+	i = skipSpace(s, i)
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		val, end := cssString(s, i)
+		end = skipSpace(s, end)
+		if end < len(s) && s[end] == ')' {
+			end++
+		}
+		return val, end
+	}
+
+	end := strings.IndexByte(s[i:], ')')
+	if end < 0 {
+		return "", len(s)
+	}
+	return strings.TrimSpace(s[i : i+end]), i + end + 1
+}
+
+// cssString reads a quoted string starting at i, returning the value and the
+// position after the closing quote.
+func cssString(s string, i int) (string, int) {
+	quote := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case quote:
+			return s[i+1 : j], j + 1
+		}
+	}
+	return "", len(s)
+}
+
+// stripComments removes all the /* */ comments from the css.
+func stripComments(s string) string {
+	var res []string
+	for {
+		begin := strings.Index(s, "/*")
+		if begin < 0 {
+			break
+		}
+		res = append(res, s[:begin])
+
+		end := strings.Index(s[begin+2:], "*/")
+		if end < 0 {
+			s = ""
+			break
+		}
+		s = s[begin+2+end+2:]
+	}
+	return strings.Join(append(res, s), " ")
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && strings.IndexByte(" \t\r\n\f", s[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func isIdent(b byte) bool {
+	return b == '-' || b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
@@ -0,0 +1,110 @@
+package document
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"golang.org/x/net/html"
+)
+
+func TestCSSRefs(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name, css string
+		refs      []ref
+	}{
+		{"empty", ``, nil},
+		{"url", `a { background: url(a.png) }`, []ref{{"a.png", KindImage}}},
+		{"quoted url", `a { background: URL( "a b.png" ) }`, []ref{{"a b.png", KindImage}}},
+		{"font", `@font-face { src: url('a.woff2?v=1') format("woff2") }`, []ref{{"a.woff2?v=1", KindFont}}},
+		{"import string", `@import "a.css" screen;`, []ref{{"a.css", KindStylesheet}}},
+		{"import url", `@IMPORT url(a.css);`, []ref{{"a.css", KindStylesheet}}},
+		{"comments", `/* url(a.png) */ a { background: url(b.png) /* @import "c.css"; */ }`, []ref{{"b.png", KindImage}}},
+		{"strings", `a::before { content: "url(a.png)" } b { background: url(b.png) }`, []ref{{"b.png", KindImage}}},
+		{"ident", `a { mask: myurl(a.png) }`, nil},
+		{"unterminated", `a { background: url(a.png`, nil},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if expected, actual := v.refs, cssRefs(v.css); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestStylesheetWalk(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("http://url.com/css/a.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	css := `@import "b.css";
+a { background: url(../images/a.png) }
+b { background: url(data:image/gif;base64,R0lGODlh) }
+@font-face { src: url(//cdn.url.com/a.woff) }`
+
+	var actual []string
+	err = NewStylesheet(u, []byte(css)).Walk(func(u *url.URL, kind Kind) error {
+		actual = append(actual, fmt.Sprintf("%s %s", kind, u.String()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"stylesheet http://url.com/css/b.css",
+		"image http://url.com/images/a.png",
+		"font http://cdn.url.com/a.woff",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestWalkStyles(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("http://url.com/a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `
+<!DOCTYPE html>
+<html>
+<head>
+<style>@import url(a.css); body { background: url(/bg.png) }</style>
+</head>
+<body>
+<div style="background-image: url('b.png')">url(c.png)</div>
+</body>
+`
+
+	node, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	NewDocument(u, node, log.NewNopLogger()).Walk(Styles(func(u *url.URL, kind Kind) error {
+		actual = append(actual, fmt.Sprintf("%s %s", kind, u.String()))
+		return nil
+	}))
+
+	expected := []string{
+		"stylesheet http://url.com/a/a.css",
+		"image http://url.com/bg.png",
+		"image http://url.com/a/b.png",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
@@ -27,12 +27,16 @@ func NewDocument(url *url.URL, node *html.Node, logger log.Logger) *Document {
 	}
 }
 
-// Walk walks through the Document node by node.
+// Walk walks through the Document node by node. The url given to the Walker is
+// the base url of the Document, which is the Documents url unless the Document
+// has a <base href>.
 func (d *Document) Walk(fn Walker) error {
+	root := d.Base()
+
 	var f func(*html.Node) error
 	f = func(n *html.Node) error {
 		if n.Type == html.ElementNode {
-			if err := fn(d.url, n); err != nil {
+			if err := fn(root, n); err != nil {
 				return err
 			}
 		}
@@ -48,66 +52,230 @@ func (d *Document) Walk(fn Walker) error {
 	return f(d.node)
 }
 
+// Base returns the url that relative urls in the Document are resolved
+// against, honouring the first <base href> in the Document.
+func (d *Document) Base() *url.URL {
+	var f func(*html.Node) *url.URL
+	f = func(n *html.Node) *url.URL {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Base {
+			for _, a := range n.Attr {
+				if a.Key != "href" {
+					continue
+				}
+				if u, err := url.Parse(strings.TrimSpace(a.Val)); err == nil {
+					return d.url.ResolveReference(u)
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if u := f(c); u != nil {
+				return u
+			}
+		}
+		return nil
+	}
+
+	if u := f(d.node); u != nil {
+		return u
+	}
+	return d.url
+}
+
 // Walker describes a type that can walk over a documents nodes.
 type Walker func(*url.URL, *html.Node) error
 
-// Links walks through all the Documents nodes links.
-// Note: it will normalize the documents links to the documents url.
-func Links(fn func(*url.URL) error) Walker {
+// Refs walks through all the Documents nodes links and static assets, along
+// with the Kind of each of them.
+// Note: it will normalize the documents urls to the documents url.
+func Refs(fn func(*url.URL, Kind) error) Walker {
 	return func(root *url.URL, node *html.Node) error {
-		// We only care about "anchor" links
-		if node.DataAtom == atom.A {
-			for _, a := range node.Attr {
-				// Pluck the "href" from all "a" links.
-				if a.Key == "href" {
-					if u, ok := normalizeLink(root, a.Val); ok {
-						fn(u)
-					}
-				}
+		for _, v := range refs(node) {
+			if u, ok := normalizeLink(root, v.val); ok && !rejected(u) {
+				fn(u, v.kind)
 			}
 		}
-
 		return nil
 	}
 }
 
+// Links walks through all the Documents nodes links.
+// Note: it will normalize the documents links to the documents url.
+func Links(fn func(*url.URL) error) Walker {
+	return Refs(func(u *url.URL, kind Kind) error {
+		if kind.Link() {
+			return fn(u)
+		}
+		return nil
+	})
+}
+
 // Assets walks through all the Documents nodes static assets.
 // Note: it will normalize the documents assets urls to the documents url.
 func Assets(fn func(*url.URL) error) func(*url.URL, *html.Node) error {
+	return Refs(func(u *url.URL, kind Kind) error {
+		if kind.Asset() {
+			return fn(u)
+		}
+		return nil
+	})
+}
+
+// Rejected walks through all the Documents nodes links and static assets that
+// can't be crawled, because of their scheme (mailto:, javascript:, tel:, data:
+// etc).
+func Rejected(fn func(*url.URL) error) Walker {
 	return func(root *url.URL, node *html.Node) error {
-		// We only care about "anchor" links
-		switch node.DataAtom {
-		case atom.Img:
-			for _, a := range node.Attr {
-				// Pluck the "src" from all "img" links.
-				if a.Key == "src" {
-					if u, ok := normalizeLink(root, a.Val); ok {
-						fn(u)
-					}
-				}
+		for _, v := range refs(node) {
+			if u, ok := normalizeLink(root, v.val); ok && rejected(u) {
+				fn(u)
 			}
-		case atom.Link:
-			var found bool
-			for _, a := range node.Attr {
-				if a.Key == "rel" && a.Val == "stylesheet" {
-					found = true
-					break
-				}
+		}
+		return nil
+	}
+}
+
+type ref struct {
+	val  string
+	kind Kind
+}
+
+// refs returns the raw values of the links and static assets of a node.
+func refs(node *html.Node) []ref {
+	switch node.DataAtom {
+	case atom.A:
+		return kindOf(KindAnchor, attrs(node, "href"))
+	case atom.Area:
+		return kindOf(KindArea, attrs(node, "href"))
+	case atom.Iframe, atom.Frame:
+		return kindOf(KindFrame, attrs(node, "src"))
+	case atom.Form:
+		return kindOf(KindForm, attrs(node, "action"))
+	case atom.Script:
+		return kindOf(KindScript, attrs(node, "src"))
+	case atom.Img:
+		return kindOf(KindImage, append(attrs(node, "src"), srcset(node)...))
+	case atom.Video:
+		return append(
+			kindOf(KindMedia, attrs(node, "src")),
+			kindOf(KindImage, attrs(node, "poster"))...,
+		)
+	case atom.Audio:
+		return kindOf(KindMedia, attrs(node, "src"))
+	case atom.Track:
+		return kindOf(KindTrack, attrs(node, "src"))
+	case atom.Source:
+		// A source is an image with in a picture, otherwise it's the source of
+		// a video or audio.
+		if node.Parent != nil && node.Parent.DataAtom == atom.Picture {
+			return kindOf(KindImage, append(attrs(node, "src"), srcset(node)...))
+		}
+		return kindOf(KindMedia, attrs(node, "src"))
+	case atom.Object:
+		return kindOf(KindObject, attrs(node, "data"))
+	case atom.Embed:
+		return kindOf(KindObject, attrs(node, "src"))
+	case atom.Link:
+		if kind, ok := linkKind(node); ok {
+			return kindOf(kind, attrs(node, "href"))
+		}
+	case atom.Meta:
+		return kindOf(KindRefresh, refresh(node))
+	}
+	return nil
+}
+
+// kindOf returns the values as refs of the same kind.
+func kindOf(kind Kind, values []string) []ref {
+	res := make([]ref, len(values))
+	for k, v := range values {
+		res[k] = ref{v, kind}
+	}
+	return res
+}
+
+// linkKind returns the Kind of a <link> from it's rel attribute. The rel
+// attribute is a space separated list, the first known value is used.
+func linkKind(node *html.Node) (Kind, bool) {
+	for _, v := range attrs(node, "rel") {
+		for _, rel := range strings.Fields(strings.ToLower(v)) {
+			switch rel {
+			case "stylesheet":
+				return KindStylesheet, true
+			case "preload":
+				return KindPreload, true
+			case "icon", "apple-touch-icon":
+				return KindIcon, true
+			case "manifest":
+				return KindManifest, true
+			case "alternate":
+				return KindAlternate, true
+			case "next":
+				return KindNext, true
+			case "prev":
+				return KindPrev, true
+			case "canonical":
+				return KindCanonical, true
 			}
+		}
+	}
+	return "", false
+}
 
-			if found {
-				for _, a := range node.Attr {
-					if a.Key == "href" {
-						if u, ok := normalizeLink(root, a.Val); ok {
-							fn(u)
-						}
-					}
-				}
+// srcset returns the urls of the image candidates of a srcset attribute.
+// For example: srcset="image-1x.png 1x, image-2x.png 2x"
+func srcset(node *html.Node) []string {
+	var res []string
+	for _, v := range attrs(node, "srcset") {
+		for _, candidate := range strings.Split(v, ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				res = append(res, fields[0])
 			}
 		}
+	}
+	return res
+}
 
+// refresh returns the url of a <meta http-equiv="refresh">.
+// For example: content="5; url=http://url.com"
+func refresh(node *html.Node) []string {
+	var equiv bool
+	for _, v := range attrs(node, "http-equiv") {
+		equiv = equiv || strings.EqualFold(strings.TrimSpace(v), "refresh")
+	}
+	if !equiv {
 		return nil
 	}
+
+	var res []string
+	for _, v := range attrs(node, "content") {
+		i := strings.Index(v, ";")
+		if i < 0 {
+			continue
+		}
+		val := strings.TrimSpace(v[i+1:])
+		if len(val) < 4 || !strings.EqualFold(val[:3], "url") {
+			continue
+		}
+		val = strings.TrimSpace(val[3:])
+		if !strings.HasPrefix(val, "=") {
+			continue
+		}
+		val = strings.Trim(strings.TrimSpace(val[1:]), `"'`)
+		res = append(res, val)
+	}
+	return res
+}
+
+// attrs returns the values of all the attributes of a node with the key.
+func attrs(node *html.Node, key string) []string {
+	var res []string
+	for _, a := range node.Attr {
+		if a.Key == key {
+			res = append(res, a.Val)
+		}
+	}
+	return res
 }
 
 // Compose attempts to compose two walkers together to allow a very basic
@@ -121,27 +289,26 @@ func Compose(fn1, fn2 Walker) Walker {
 	}
 }
 
+// normalizeLink resolves a link against the root url, as defined in RFC 3986.
 func normalizeLink(root *url.URL, val string) (*url.URL, bool) {
-	// This is a page anchor tag, we don't care about these.
-	if strings.HasPrefix(val, "#") {
+	// This is a page anchor tag or the page itself, we don't care about these.
+	val = strings.TrimSpace(val)
+	if val == "" || strings.HasPrefix(val, "#") {
 		return nil, false
 	}
 
-	// If it's a relative url to the root, then normalize it.
 	n, err := url.Parse(val)
 	if err != nil {
 		return nil, false
 	}
 
-	var norm *url.URL
-	if strings.HasPrefix(val, "/") {
-		norm = root.ResolveReference(n)
-	} else {
-		norm = n
-	}
-
-	return norm, true
+	return root.ResolveReference(n), true
+}
 
+// rejected returns if the url has a scheme that can't be crawled.
+func rejected(u *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	return scheme != "http" && scheme != "https"
 }
 
 // Extract the scheme and host out of the url.
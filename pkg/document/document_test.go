@@ -1,6 +1,7 @@
 package document
 
 import (
+	"fmt"
 	"net/url"
 	"reflect"
 	"strings"
@@ -180,6 +181,149 @@ func TestWalkLinks(t *testing.T) {
 	})
 }
 
+func TestWalkLinksRelative(t *testing.T) {
+	t.Parallel()
+
+	fn := func(addr, body string) []string {
+		u, err := url.Parse(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node, err := html.Parse(strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			actual []string
+			doc    = NewDocument(u, node, log.NewNopLogger())
+		)
+		doc.Walk(Links(func(url *url.URL) error {
+			actual = append(actual, url.String())
+			return nil
+		}))
+
+		return actual
+	}
+
+	t.Run("relative", func(t *testing.T) {
+		body := `
+<!DOCTYPE html>
+<html>
+<body>
+<a href="page2.html">page2</a>
+<a href="../about">about</a>
+<a href="?page=2">page</a>
+<a href="//cdn.url.com/x">cdn</a>
+<a href="./">dir</a>
+</body>
+`
+		urls := []string{
+			"http://url.com/a/page2.html",
+			"http://url.com/about",
+			"http://url.com/a/b?page=2",
+			"http://cdn.url.com/x",
+			"http://url.com/a/",
+		}
+
+		if expected, actual := urls, fn("http://url.com/a/b", body); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("base", func(t *testing.T) {
+		body := `
+<!DOCTYPE html>
+<html>
+<head>
+<base href="/docs/">
+<base href="http://other.com/">
+</head>
+<body>
+<a href="page2.html">page2</a>
+<a href="/root">root</a>
+</body>
+`
+		urls := []string{
+			"http://url.com/docs/page2.html",
+			"http://url.com/root",
+		}
+
+		if expected, actual := urls, fn("http://url.com/a/b", body); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		body := `
+<!DOCTYPE html>
+<html>
+<body>
+<a href="mailto:a@url.com">mail</a>
+<a href="javascript:void(0)">js</a>
+<a href="tel:+44">tel</a>
+<a href="">empty</a>
+<a href="/link">link</a>
+</body>
+`
+		urls := []string{
+			"http://url.com/link",
+		}
+
+		if expected, actual := urls, fn("http://url.com", body); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+}
+
+func TestWalkRejected(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("http://url.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `
+<!DOCTYPE html>
+<html>
+<body>
+<a href="mailto:a@url.com">mail</a>
+<a href="javascript:void(0)">js</a>
+<a href="tel:+44">tel</a>
+<a href="/link">link</a>
+<img src="data:image/gif;base64,R0lGODlh" />
+<img src="/image.gif" />
+</body>
+`
+
+	node, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		actual []string
+		doc    = NewDocument(u, node, log.NewNopLogger())
+	)
+	doc.Walk(Rejected(func(url *url.URL) error {
+		actual = append(actual, url.String())
+		return nil
+	}))
+
+	urls := []string{
+		"mailto:a@url.com",
+		"javascript:void(0)",
+		"tel:+44",
+		"data:image/gif;base64,R0lGODlh",
+	}
+
+	if expected := urls; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
 func TestWalkAssets(t *testing.T) {
 	t.Parallel()
 
@@ -414,3 +558,104 @@ func BenchmarkAssets(b *testing.B) {
 		}))
 	}
 }
+
+func TestWalkRefs(t *testing.T) {
+	t.Parallel()
+
+	fn := func(body string) []string {
+		u, err := url.Parse("http://url.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node, err := html.Parse(strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			actual []string
+			doc    = NewDocument(u, node, log.NewNopLogger())
+		)
+		doc.Walk(Refs(func(url *url.URL, kind Kind) error {
+			actual = append(actual, fmt.Sprintf("%s %s", kind, url.String()))
+			return nil
+		}))
+
+		return actual
+	}
+
+	for _, v := range []struct {
+		name, body string
+		refs       []string
+	}{
+		{"anchor", `<a href="/a">a</a>`, []string{"anchor http://url.com/a"}},
+		{"area", `<map><area href="/a"></map>`, []string{"area http://url.com/a"}},
+		{"iframe", `<iframe src="/a"></iframe>`, []string{"frame http://url.com/a"}},
+		{"form", `<form action="/a"></form>`, []string{"form http://url.com/a"}},
+		{"script", `<script src="/a.js"></script><script>var a;</script>`, []string{"script http://url.com/a.js"}},
+		{"img srcset", `<img src="/a.png" srcset="/a-1x.png 1x, /a-2x.png 2x">`, []string{
+			"image http://url.com/a.png",
+			"image http://url.com/a-1x.png",
+			"image http://url.com/a-2x.png",
+		}},
+		{"picture", `<picture><source srcset="/a.webp 100w"><img src="/a.png"></picture>`, []string{
+			"image http://url.com/a.webp",
+			"image http://url.com/a.png",
+		}},
+		{"video", `<video src="/a.mp4" poster="/a.png"><source src="/a.webm"><track src="/a.vtt"></video>`, []string{
+			"media http://url.com/a.mp4",
+			"image http://url.com/a.png",
+			"media http://url.com/a.webm",
+			"track http://url.com/a.vtt",
+		}},
+		{"audio", `<audio src="/a.mp3"></audio>`, []string{"media http://url.com/a.mp3"}},
+		{"object", `<object data="/a.swf"></object><embed src="/b.swf">`, []string{
+			"object http://url.com/a.swf",
+			"object http://url.com/b.swf",
+		}},
+		{"link rel", `<link rel="preload" href="/a.woff"><link rel="shortcut icon" href="/a.ico"><link rel="manifest" href="/a.json"><link rel="alternate" href="/a.rss"><link rel="next" href="/2"><link rel="prev" href="/0"><link rel="canonical" href="/c"><link rel="dns-prefetch" href="//cdn.url.com">`, []string{
+			"preload http://url.com/a.woff",
+			"icon http://url.com/a.ico",
+			"manifest http://url.com/a.json",
+			"alternate http://url.com/a.rss",
+			"next http://url.com/2",
+			"prev http://url.com/0",
+			"canonical http://url.com/c",
+		}},
+		{"meta refresh", `<meta http-equiv="Refresh" content="5; URL='/a'"><meta http-equiv="refresh" content="5"><meta name="refresh" content="0; url=/b">`, []string{
+			"refresh http://url.com/a",
+		}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if expected, actual := v.refs, fn(v.body); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestKind(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		kind        Kind
+		link, asset bool
+	}{
+		{KindAnchor, true, false},
+		{KindRefresh, true, false},
+		{KindImage, false, true},
+		{KindPreload, false, true},
+		{KindForm, false, false},
+		{KindCanonical, false, false},
+	} {
+		t.Run(string(v.kind), func(t *testing.T) {
+			if expected, actual := v.link, v.kind.Link(); expected != actual {
+				t.Errorf("expected: %t, actual: %t", expected, actual)
+			}
+			if expected, actual := v.asset, v.kind.Asset(); expected != actual {
+				t.Errorf("expected: %t, actual: %t", expected, actual)
+			}
+		})
+	}
+}
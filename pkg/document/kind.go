@@ -0,0 +1,48 @@
+package document
+
+// Kind describes where in a Document a url was found, so that urls can be
+// grouped by type.
+type Kind string
+
+// The kinds of urls that can be found in a Document.
+const (
+	KindAnchor     Kind = "anchor"
+	KindArea       Kind = "area"
+	KindFrame      Kind = "frame"
+	KindForm       Kind = "form"
+	KindAlternate  Kind = "alternate"
+	KindNext       Kind = "next"
+	KindPrev       Kind = "prev"
+	KindRefresh    Kind = "refresh"
+	KindScript     Kind = "script"
+	KindStylesheet Kind = "stylesheet"
+	KindImage      Kind = "image"
+	KindMedia      Kind = "media"
+	KindTrack      Kind = "track"
+	KindObject     Kind = "object"
+	KindPreload    Kind = "preload"
+	KindIcon       Kind = "icon"
+	KindManifest   Kind = "manifest"
+	KindFont       Kind = "font"
+	KindCanonical  Kind = "canonical"
+)
+
+// Link returns if the Kind is a link to another page, which can be crawled.
+func (k Kind) Link() bool {
+	switch k {
+	case KindAnchor, KindArea, KindFrame, KindAlternate, KindNext, KindPrev, KindRefresh:
+		return true
+	}
+	return false
+}
+
+// Asset returns if the Kind is a static asset of a page.
+// Note: forms are neither a link or an asset, as submitting a form can have
+// side effects. Neither is the canonical url of a page.
+func (k Kind) Asset() bool {
+	switch k {
+	case KindScript, KindStylesheet, KindImage, KindMedia, KindTrack, KindObject, KindPreload, KindIcon, KindManifest, KindFont:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package document
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Directives are the page level robots directives, which can be set with a
+// <meta name="robots"> or a X-Robots-Tag header.
+type Directives struct {
+	// NoIndex is true if the page shouldn't be indexed.
+	NoIndex bool
+	// NoFollow is true if none of the links of the page should be followed.
+	NoFollow bool
+}
+
+// Merge combines the directives, the most restrictive directive wins.
+func (d Directives) Merge(o Directives) Directives {
+	return Directives{
+		NoIndex:  d.NoIndex || o.NoIndex,
+		NoFollow: d.NoFollow || o.NoFollow,
+	}
+}
+
+// parse parses a comma separated list of directives, for example
+// "noindex, nofollow".
+func (d Directives) parse(content string) Directives {
+	for _, v := range strings.Split(content, ",") {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "noindex":
+			d.NoIndex = true
+		case "nofollow":
+			d.NoFollow = true
+		case "none":
+			d.NoIndex, d.NoFollow = true, true
+		}
+	}
+	return d
+}
+
+// RobotsTag parses the values of the X-Robots-Tag headers that apply to the
+// robot user agent. A value can be prefixed with the name of a robot, for
+// example "googlebot: noindex", in which case it only applies if the robot
+// user agent matches the name.
+func RobotsTag(robot string, values []string) Directives {
+	var res Directives
+	for _, v := range values {
+		if i := strings.Index(v, ":"); i >= 0 {
+			name := strings.TrimSpace(v[:i])
+			// Directives with a value (unavailable_after: date) aren't robot
+			// names.
+			if !strings.ContainsAny(name, " ,") && !strings.EqualFold(name, "unavailable_after") {
+				if !matchRobot(robot, name) {
+					continue
+				}
+				v = v[i+1:]
+			}
+		}
+		res = res.parse(v)
+	}
+	return res
+}
+
+// MetaRobots walks through all the Documents <meta name="robots"> tags, along
+// with the <meta> tags that are named after the robot user agent.
+func MetaRobots(robot string, fn func(Directives) error) Walker {
+	return func(root *url.URL, node *html.Node) error {
+		if node.DataAtom != atom.Meta {
+			return nil
+		}
+		for _, name := range attrs(node, "name") {
+			if name = strings.TrimSpace(name); strings.EqualFold(name, "robots") || matchRobot(robot, name) {
+				var d Directives
+				for _, v := range attrs(node, "content") {
+					d = d.parse(v)
+				}
+				return fn(d)
+			}
+		}
+		return nil
+	}
+}
+
+// NoFollow walks through all the Documents links that have a rel="nofollow".
+// Note: it will normalize the documents links to the documents url.
+func NoFollow(fn func(*url.URL) error) Walker {
+	return func(root *url.URL, node *html.Node) error {
+		if node.DataAtom != atom.A && node.DataAtom != atom.Area {
+			return nil
+		}
+
+		var nofollow bool
+		for _, v := range attrs(node, "rel") {
+			for _, rel := range strings.Fields(strings.ToLower(v)) {
+				nofollow = nofollow || rel == "nofollow"
+			}
+		}
+		if !nofollow {
+			return nil
+		}
+
+		for _, v := range attrs(node, "href") {
+			if u, ok := normalizeLink(root, v); ok && !rejected(u) {
+				fn(u)
+			}
+		}
+		return nil
+	}
+}
+
+// matchRobot returns if the name of a robot matches one of the product tokens
+// of the robot user agent, for example "Googlebot" or "crwlr" for
+// "Googlebot (crwlr/0.1)".
+func matchRobot(robot, name string) bool {
+	if name == "" {
+		return false
+	}
+	tokens := strings.FieldsFunc(robot, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("();,", r)
+	})
+	for _, v := range tokens {
+		if i := strings.Index(v, "/"); i >= 0 {
+			v = v[:i]
+		}
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
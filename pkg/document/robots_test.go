@@ -0,0 +1,118 @@
+package document
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"golang.org/x/net/html"
+)
+
+func TestRobotsTag(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name     string
+		values   []string
+		expected Directives
+	}{
+		{"empty", nil, Directives{}},
+		{"noindex", []string{"noindex"}, Directives{NoIndex: true}},
+		{"list", []string{"NoIndex, NoFollow"}, Directives{NoIndex: true, NoFollow: true}},
+		{"none", []string{"none"}, Directives{NoIndex: true, NoFollow: true}},
+		{"headers", []string{"noindex", "nofollow"}, Directives{NoIndex: true, NoFollow: true}},
+		{"robot", []string{"googlebot: nofollow"}, Directives{NoFollow: true}},
+		{"other robot", []string{"bingbot: noindex"}, Directives{}},
+		{"product", []string{"CRWLR: noindex"}, Directives{NoIndex: true}},
+		{"partial robot", []string{"bot: noindex"}, Directives{}},
+		{"unavailable after", []string{"unavailable_after: 25 Jun 2010 15:00:00 PST"}, Directives{}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if actual := RobotsTag("Googlebot (crwlr/0.1)", v.values); !reflect.DeepEqual(v.expected, actual) {
+				t.Errorf("expected: %v, actual: %v", v.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWalkMetaRobots(t *testing.T) {
+	t.Parallel()
+
+	fn := func(body string) Directives {
+		u, err := url.Parse("http://url.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		node, err := html.Parse(strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual Directives
+		NewDocument(u, node, log.NewNopLogger()).Walk(MetaRobots("Googlebot (crwlr/0.1)", func(d Directives) error {
+			actual = actual.Merge(d)
+			return nil
+		}))
+		return actual
+	}
+
+	for _, v := range []struct {
+		name, body string
+		expected   Directives
+	}{
+		{"none", `<meta name="description" content="noindex">`, Directives{}},
+		{"robots", `<meta name="robots" content="noindex,nofollow">`, Directives{NoIndex: true, NoFollow: true}},
+		{"robot", `<meta name="Googlebot" content="noindex">`, Directives{NoIndex: true}},
+		{"other robot", `<meta name="bingbot" content="noindex">`, Directives{}},
+		{"partial robot", `<meta name="google" content="noindex">`, Directives{}},
+		{"merge", `<meta name="robots" content="index"><meta name="googlebot" content="nofollow">`, Directives{NoFollow: true}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if actual := fn(v.body); !reflect.DeepEqual(v.expected, actual) {
+				t.Errorf("expected: %v, actual: %v", v.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWalkNoFollow(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("http://url.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `
+<!DOCTYPE html>
+<html>
+<body>
+<a href="/link">link</a>
+<a href="/nofollow" rel="nofollow">nofollow</a>
+<a href="/sponsored" rel="Sponsored NoFollow">sponsored</a>
+<link rel="nofollow" href="/ignored">
+</body>
+`
+
+	node, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	NewDocument(u, node, log.NewNopLogger()).Walk(NoFollow(func(u *url.URL) error {
+		actual = append(actual, u.String())
+		return nil
+	}))
+
+	expected := []string{
+		"http://url.com/nofollow",
+		"http://url.com/sponsored",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
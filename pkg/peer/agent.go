@@ -65,7 +65,10 @@ func (a *Agent) Request(ctx *AgentContext, t AgentType) (*http.Response, error)
 		return nil, err
 	}
 
-	ctx.With(context.WithTimeout(req.Context(), ctx.Timeout))
+	// Make sure the request can not out live the timeout, or the context it was
+	// created from.
+	ctx.With(context.WithTimeout(ctx.Context, ctx.Timeout))
+	req = req.WithContext(ctx.Context)
 
 	req.Header.Set("User-Agent", a.userAgent.Type(t))
 	return a.client.Do(req)
@@ -80,10 +83,12 @@ type AgentContext struct {
 	cancelFn context.CancelFunc
 }
 
-// NewAgentContext creates a new AgentContext from a given url.URL
+// NewAgentContext creates a new AgentContext from a given context.Context and
+// url.URL. Cancelling the parent context will cancel any request using the
+// AgentContext.
 // Note: Default time out is set to 10 seconds.
-func NewAgentContext(u *url.URL) *AgentContext {
-	context, cancelFn := context.WithCancel(context.Background())
+func NewAgentContext(ctx context.Context, u *url.URL) *AgentContext {
+	context, cancelFn := context.WithCancel(ctx)
 
 	return &AgentContext{
 		URL:      u,
@@ -95,6 +100,17 @@ func NewAgentContext(u *url.URL) *AgentContext {
 
 // With takes a context and a cancelFn so it's possible to chain cancelling.
 func (a *AgentContext) With(context context.Context, cancelFn context.CancelFunc) {
+	parent := a.cancelFn
+
 	a.Context = context
-	a.cancelFn = cancelFn
+	a.cancelFn = func() {
+		cancelFn()
+		parent()
+	}
+}
+
+// Cancel cancels the context, aborting any request that's still using it.
+// Note: the response body can not be read once cancelled.
+func (a *AgentContext) Cancel() {
+	a.cancelFn()
 }
@@ -1,11 +1,13 @@
 package peer
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
 	"testing/quick"
+	"time"
 
 	"github.com/SimonRichardson/crwlr/pkg/test"
 	"github.com/go-kit/kit/log"
@@ -61,7 +63,7 @@ func TestRequest(t *testing.T) {
 	}
 
 	agent := NewAgent(client, userAgent, log.NewNopLogger())
-	if _, err := agent.Request(NewAgentContext(u), Host); err != nil {
+	if _, err := agent.Request(NewAgentContext(context.Background(), u), Host); err != nil {
 		t.Fatal(err)
 	}
 
@@ -69,7 +71,7 @@ func TestRequest(t *testing.T) {
 		t.Errorf("expected: %s, actual: %s", expected, actual)
 	}
 
-	if _, err := agent.Request(NewAgentContext(u), Robot); err != nil {
+	if _, err := agent.Request(NewAgentContext(context.Background(), u), Robot); err != nil {
 		t.Fatal(err)
 	}
 
@@ -77,3 +79,54 @@ func TestRequest(t *testing.T) {
 		t.Errorf("expected: %s, actual: %s", expected, actual)
 	}
 }
+
+func TestRequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	})
+
+	var (
+		server    = httptest.NewServer(mux)
+		client    = http.DefaultClient
+		userAgent = NewUserAgent("host", "robot")
+	)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := NewAgent(client, userAgent, log.NewNopLogger())
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx := NewAgentContext(context.Background(), u)
+		ctx.Timeout = time.Millisecond * 10
+		defer ctx.Cancel()
+
+		if _, err := agent.Request(ctx, Host); err == nil {
+			t.Error("expected request to timeout")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		ctx := NewAgentContext(parent, u)
+		defer ctx.Cancel()
+
+		time.AfterFunc(time.Millisecond*10, cancel)
+
+		if _, err := agent.Request(ctx, Host); err == nil {
+			t.Error("expected request to be cancelled")
+		}
+	})
+}
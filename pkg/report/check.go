@@ -0,0 +1,199 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Check is the outcome of requesting a url.
+type Check struct {
+	// StatusCode is the status code of the response, zero if there was no
+	// response.
+	StatusCode int
+	// Err describes why the url couldn't be requested, if it couldn't be.
+	Err string
+}
+
+// Broken returns true if the url couldn't be requested or the host responded
+// with an error.
+func (c Check) Broken() bool {
+	return c.Err != "" || c.StatusCode <= 0 || c.StatusCode >= 400
+}
+
+func (c Check) String() string {
+	switch {
+	case c.Err != "" && c.StatusCode > 0:
+		return fmt.Sprintf("%d %s", c.StatusCode, c.Err)
+	case c.Err != "":
+		return c.Err
+	case c.StatusCode > 0:
+		return fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode))
+	default:
+		return "no response"
+	}
+}
+
+// BrokenRef is a url that's broken, along with the pages that reference it.
+type BrokenRef struct {
+	URL   string
+	Check Check
+	Pages []string
+}
+
+// Allowlist holds the urls that are known to be broken, so they aren't
+// reported. A url ending in * matches every url with that prefix.
+type Allowlist struct {
+	urls     map[string]struct{}
+	prefixes []string
+}
+
+// NewAllowlist creates an Allowlist from the urls.
+func NewAllowlist(urls []string) *Allowlist {
+	a := &Allowlist{urls: map[string]struct{}{}}
+	for _, v := range urls {
+		if strings.HasSuffix(v, "*") {
+			a.prefixes = append(a.prefixes, strings.TrimSuffix(v, "*"))
+			continue
+		}
+		a.urls[v] = struct{}{}
+	}
+	return a
+}
+
+// ReadAllowlist reads an Allowlist of one url per line, ignoring empty lines
+// and lines starting with #.
+func ReadAllowlist(r io.Reader) (*Allowlist, error) {
+	var (
+		urls    []string
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to read allowlist")
+	}
+	return NewAllowlist(urls), nil
+}
+
+// Allowed returns true if the url is allowed to be broken.
+func (a *Allowlist) Allowed(u string) bool {
+	if a == nil {
+		return false
+	}
+	if _, ok := a.urls[u]; ok {
+		return true
+	}
+	for _, v := range a.prefixes {
+		if strings.HasPrefix(u, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckReport lists the refs of every page of a crawl that are broken.
+type CheckReport struct {
+	refs      map[string][]string
+	checks    map[string]Check
+	allowlist *Allowlist
+}
+
+// NewCheckReport generates a report from the refs of every page and the
+// outcome of requesting each of the refs. Refs without a check weren't
+// requested, so they're never reported as broken.
+func NewCheckReport(refs map[string][]string, checks map[string]Check) *CheckReport {
+	res := make(map[string][]string, len(refs))
+	for k, v := range refs {
+		res[k] = dedupe(v)
+	}
+	return &CheckReport{
+		refs:   res,
+		checks: checks,
+	}
+}
+
+// Allow sets the urls that are known to be broken, so they aren't reported.
+func (r *CheckReport) Allow(a *Allowlist) {
+	r.allowlist = a
+}
+
+// Pages returns the pages of the crawl, sorted by their url.
+func (r *CheckReport) Pages() []string {
+	res := make([]string, 0, len(r.refs))
+	for k := range r.refs {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Refs returns the refs of the page that were checked, sorted by their url.
+func (r *CheckReport) Refs(page string) []string {
+	var res []string
+	for _, v := range r.refs[page] {
+		if _, ok := r.checks[v]; ok {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// Check returns the outcome of requesting the url, if it was requested.
+func (r *CheckReport) Check(u string) (Check, bool) {
+	c, ok := r.checks[u]
+	return c, ok
+}
+
+// Failed returns true if the url is broken and isn't allowed to be.
+func (r *CheckReport) Failed(u string) bool {
+	c, ok := r.checks[u]
+	return ok && c.Broken() && !r.allowlist.Allowed(u)
+}
+
+// Broken returns the refs that are broken, sorted by their url, along with the
+// pages that reference them.
+func (r *CheckReport) Broken() []BrokenRef {
+	pages := map[string][]string{}
+	for _, page := range r.Pages() {
+		for _, v := range r.refs[page] {
+			if r.Failed(v) {
+				pages[v] = append(pages[v], page)
+			}
+		}
+	}
+
+	res := make([]BrokenRef, 0, len(pages))
+	for k, v := range pages {
+		res = append(res, BrokenRef{
+			URL:   k,
+			Check: r.checks[k],
+			Pages: v,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].URL < res[j].URL
+	})
+	return res
+}
+
+func (r *CheckReport) Write(w io.Writer) error {
+	fmt.Fprintln(w, " URL\t Status\t Pages\t")
+	for _, v := range r.Broken() {
+		fmt.Fprintf(w, " %s\t %s\t \t\n", v.URL, v.Check)
+		for _, page := range v.Pages {
+			fmt.Fprintf(w, " \t \t %s\t\n", page)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name   string
+		check  Check
+		broken bool
+		str    string
+	}{
+		{"ok", Check{StatusCode: 200}, false, "200 OK"},
+		{"redirect", Check{StatusCode: 301}, false, "301 Moved Permanently"},
+		{"not found", Check{StatusCode: 404}, true, "404 Not Found"},
+		{"no response", Check{}, true, "no response"},
+		{"error", Check{Err: "no such host"}, true, "no such host"},
+		{"unreadable", Check{StatusCode: 200, Err: "unable to read response"}, true, "200 unable to read response"},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if expected, actual := v.broken, v.check.Broken(); expected != actual {
+				t.Errorf("expected: %t, actual: %t", expected, actual)
+			}
+			if expected, actual := v.str, v.check.String(); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestReadAllowlist(t *testing.T) {
+	t.Parallel()
+
+	a, err := ReadAllowlist(strings.NewReader("# known\n\nhttp://a.com/b\n http://b.com/* \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		url     string
+		allowed bool
+	}{
+		{"http://a.com/b", true},
+		{"http://a.com/b/c", false},
+		{"http://b.com/", true},
+		{"http://b.com/c.png", true},
+		{"# known", false},
+	} {
+		if expected, actual := v.allowed, a.Allowed(v.url); expected != actual {
+			t.Errorf("%s expected: %t, actual: %t", v.url, expected, actual)
+		}
+	}
+
+	var nilAllowlist *Allowlist
+	if nilAllowlist.Allowed("http://a.com/b") {
+		t.Errorf("expected: false, actual: true")
+	}
+}
+
+func TestCheckReport(t *testing.T) {
+	t.Parallel()
+
+	newReport := func() *CheckReport {
+		return NewCheckReport(map[string][]string{
+			"http://a.com":   {"http://a.com/b", "http://a.com/c", "http://b.com", "http://a.com/b"},
+			"http://a.com/b": {"http://a.com/c", "http://a.com/d.png", "http://a.com/skipped"},
+		}, map[string]Check{
+			"http://a.com":       {StatusCode: 200},
+			"http://a.com/b":     {StatusCode: 200},
+			"http://a.com/c":     {StatusCode: 404},
+			"http://a.com/d.png": {StatusCode: 500},
+			"http://b.com":       {Err: "no such host"},
+		})
+	}
+
+	t.Run("refs", func(t *testing.T) {
+		r := newReport()
+
+		if expected, actual := []string{"http://a.com", "http://a.com/b"}, r.Pages(); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+		if expected, actual := []string{"http://a.com/c", "http://a.com/d.png"}, r.Refs("http://a.com/b"); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("broken", func(t *testing.T) {
+		r := newReport()
+
+		expected := []BrokenRef{
+			{"http://a.com/c", Check{StatusCode: 404}, []string{"http://a.com", "http://a.com/b"}},
+			{"http://a.com/d.png", Check{StatusCode: 500}, []string{"http://a.com/b"}},
+			{"http://b.com", Check{Err: "no such host"}, []string{"http://a.com"}},
+		}
+		if actual := r.Broken(); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		r := newReport()
+		r.Allow(NewAllowlist([]string{"http://b.com", "http://a.com/d*"}))
+
+		if expected, actual := 1, len(r.Broken()); expected != actual {
+			t.Fatalf("expected: %d, actual: %d", expected, actual)
+		}
+		if r.Failed("http://b.com") {
+			t.Errorf("expected: false, actual: true")
+		}
+		if !r.Failed("http://a.com/c") {
+			t.Errorf("expected: true, actual: false")
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := newReport().Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := " URL\t Status\t Pages\t\n" +
+			" http://a.com/c\t 404 Not Found\t \t\n" +
+			" \t \t http://a.com\t\n" +
+			" \t \t http://a.com/b\t\n" +
+			" http://a.com/d.png\t 500 Internal Server Error\t \t\n" +
+			" \t \t http://a.com/b\t\n" +
+			" http://b.com\t no such host\t \t\n" +
+			" \t \t http://a.com\t\n"
+		if actual := buf.String(); expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// CoverageReport compares the urls in the sitemaps of a crawl against the
+// pages that can be reached by following links.
+type CoverageReport struct {
+	unlinked, unlisted []string
+}
+
+// NewCoverageReport creates a report from the urls of the sitemaps, the urls
+// that were linked to and the urls of the pages that can be indexed.
+// A url in the sitemap that isn't linked to is unlinked, where as a page that
+// is linked to and can be indexed, but isn't in the sitemap is unlisted.
+// Without a sitemap there's nothing to compare against, so the report is empty.
+func NewCoverageReport(sitemap, linked, indexed []string) *CoverageReport {
+	if len(sitemap) == 0 {
+		return &CoverageReport{}
+	}
+
+	var (
+		inSitemap = set(sitemap)
+		isLinked  = set(linked)
+		r         = &CoverageReport{}
+	)
+	for k := range inSitemap {
+		if _, ok := isLinked[k]; !ok {
+			r.unlinked = append(r.unlinked, k)
+		}
+	}
+	for k := range set(indexed) {
+		_, linked := isLinked[k]
+		if _, ok := inSitemap[k]; linked && !ok {
+			r.unlisted = append(r.unlisted, k)
+		}
+	}
+	sort.Strings(r.unlinked)
+	sort.Strings(r.unlisted)
+	return r
+}
+
+// Unlinked returns the urls of the sitemap that can't be reached by links.
+func (r *CoverageReport) Unlinked() []string {
+	return r.unlinked
+}
+
+// Unlisted returns the pages that can be reached by links, but aren't in the
+// sitemap.
+func (r *CoverageReport) Unlisted() []string {
+	return r.unlisted
+}
+
+func (r *CoverageReport) Write(w io.Writer) error {
+	fmt.Fprintln(w, " URL\t In Sitemap\t Linked\t")
+	for _, v := range r.unlinked {
+		fmt.Fprintf(w, " %s\t %t\t %t\t\n", v, true, false)
+	}
+	for _, v := range r.unlisted {
+		fmt.Fprintf(w, " %s\t %t\t %t\t\n", v, false, true)
+	}
+	return nil
+}
+
+func set(values []string) map[string]struct{} {
+	res := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		res[v] = struct{}{}
+	}
+	return res
+}
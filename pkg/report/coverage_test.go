@@ -0,0 +1,28 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoverageReport(t *testing.T) {
+	t.Parallel()
+
+	r := NewCoverageReport(
+		[]string{"http://a.com/", "http://a.com/orphan", "http://a.com/linked"},
+		[]string{"http://a.com/", "http://a.com/linked", "http://a.com/missing", "http://a.com/bad"},
+		[]string{"http://a.com/", "http://a.com/linked", "http://a.com/missing", "http://a.com/orphan"},
+	)
+
+	if expected, actual := []string{"http://a.com/orphan"}, r.Unlinked(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []string{"http://a.com/missing"}, r.Unlisted(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	r = NewCoverageReport(nil, []string{"http://a.com/"}, []string{"http://a.com/"})
+	if expected, actual := 0, len(r.Unlisted()); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
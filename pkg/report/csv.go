@@ -0,0 +1,154 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// MetricCSVColumns are the columns of the MetricReport csv.
+	MetricCSVColumns = []string{
+		"url",
+		"avg_duration_ms",
+		"p50_ms",
+		"p90_ms",
+		"p99_ms",
+		"max_ms",
+		"bytes",
+		"requested",
+		"received",
+		"filtered",
+		"errorred",
+		"retried",
+		"skipped",
+	}
+
+	// SiteCSVColumns are the columns of the SiteReport csv.
+	SiteCSVColumns = []string{
+		"source",
+		"target",
+		"kind",
+	}
+)
+
+// SelectColumns returns the columns selected from the available columns, in
+// the order they were selected. Without a selection all the columns are
+// returned.
+func SelectColumns(available, selected []string) ([]string, error) {
+	if len(selected) == 0 {
+		return available, nil
+	}
+
+	res := make([]string, len(selected))
+	for k, v := range selected {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if !contains(available, v) {
+			return nil, errors.Errorf("%s: unknown column (expected one of %s)", v, strings.Join(available, ", "))
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+// WriteCSV writes a row for each url of the report with the selected columns.
+func (r *MetricReport) WriteCSV(w io.Writer, columns []string) error {
+	columns, err := SelectColumns(MetricCSVColumns, columns)
+	if err != nil {
+		return err
+	}
+
+	rows, err := aggregateRows(r.rows)
+	if err != nil {
+		return err
+	}
+
+	records := [][]string{columns}
+	for _, k := range r.orderRows(rows) {
+		var (
+			v = rows[k]
+			p = v.Percentiles()
+		)
+		values := map[string]string{
+			"url":             k,
+			"avg_duration_ms": strconv.FormatInt(v.Duration.Nanoseconds()/1e6, 10),
+			"p50_ms":          strconv.FormatInt(p.P50.Nanoseconds()/1e6, 10),
+			"p90_ms":          strconv.FormatInt(p.P90.Nanoseconds()/1e6, 10),
+			"p99_ms":          strconv.FormatInt(p.P99.Nanoseconds()/1e6, 10),
+			"max_ms":          strconv.FormatInt(p.Max.Nanoseconds()/1e6, 10),
+			"bytes":           strconv.FormatInt(v.Bytes(), 10),
+			"requested":       strconv.Itoa(v.Requested),
+			"received":        strconv.Itoa(v.Received),
+			"filtered":        strconv.Itoa(v.Filtered),
+			"errorred":        strconv.Itoa(v.Errorred),
+			"retried":         strconv.Itoa(v.Retried),
+			"skipped":         strconv.Itoa(v.Skipped),
+		}
+		records = append(records, selectValues(columns, values))
+	}
+	return writeCSV(w, records)
+}
+
+// WriteCSV writes the report as an edge list, with a row for each link or
+// asset that a page references.
+func (r *SiteReport) WriteCSV(w io.Writer, columns []string) error {
+	columns, err := SelectColumns(SiteCSVColumns, columns)
+	if err != nil {
+		return err
+	}
+
+	pages, err := aggregatePages(r.pages)
+	if err != nil {
+		return err
+	}
+
+	records := [][]string{columns}
+	for _, k := range r.orderPages(pages) {
+		for _, edge := range []struct {
+			kind    string
+			targets []string
+		}{
+			{"link", pages[k].Links},
+			{"asset", pages[k].Assets},
+		} {
+			for _, target := range edge.targets {
+				records = append(records, selectValues(columns, map[string]string{
+					"source": k,
+					"target": target,
+					"kind":   edge.kind,
+				}))
+			}
+		}
+	}
+	return writeCSV(w, records)
+}
+
+// writeCSV writes the records using the quoting and line endings of RFC 4180.
+func writeCSV(w io.Writer, records [][]string) error {
+	enc := csv.NewWriter(w)
+	enc.UseCRLF = true
+	if err := enc.WriteAll(records); err != nil {
+		return errors.Wrap(err, "unable to write csv")
+	}
+	return nil
+}
+
+func selectValues(columns []string, values map[string]string) []string {
+	res := make([]string, len(columns))
+	for k, v := range columns {
+		res[k] = values[v]
+	}
+	return res
+}
+
+func contains(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
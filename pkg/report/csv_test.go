@@ -0,0 +1,112 @@
+package report
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectColumns(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name     string
+		selected []string
+		expected []string
+		err      bool
+	}{
+		{"all", nil, SiteCSVColumns, false},
+		{"ordered", []string{"kind", " Source "}, []string{"kind", "source"}, false},
+		{"unknown", []string{"source", "bad"}, nil, true},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			actual, err := SelectColumns(SiteCSVColumns, v.selected)
+			if expected, actual := v.err, err != nil; expected != actual {
+				t.Fatalf("expected: %t, actual: %t (%v)", expected, actual, err)
+			}
+			if expected := v.expected; !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestMetricReport_WriteCSV(t *testing.T) {
+	t.Parallel()
+
+	r := NewMetricReport(time.Second, map[string]*Row{
+		"http://a.com/b": {
+			Requested: 1,
+			Received:  1,
+			Duration:  2 * time.Millisecond,
+			Latencies: []Latency{{Total: 2 * time.Millisecond, Bytes: 100}},
+		},
+		"http://a.com/b?c=1": {
+			Requested: 1,
+			Received:  1,
+			Duration:  4 * time.Millisecond,
+			Latencies: []Latency{{Total: 4 * time.Millisecond, Bytes: 50}},
+		},
+		`http://a.com/"d",e`:  {Requested: 1, Errorred: 1},
+		"http://a.com/filter": {Filtered: 2},
+	})
+
+	t.Run("all", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := r.WriteCSV(&buf, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "url,avg_duration_ms,p50_ms,p90_ms,p99_ms,max_ms,bytes,requested,received,filtered,errorred,retried,skipped\r\n" +
+			"\"http://a.com/%22d%22,e\",0,0,0,0,0,0,1,0,0,1,0,0\r\n" +
+			"http://a.com/b,3,2,4,4,4,150,2,2,0,0,0,0\r\n" +
+			"http://a.com/filter,0,0,0,0,0,0,0,0,2,0,0,0\r\n"
+		if actual := buf.String(); expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+	})
+
+	t.Run("columns", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := r.WriteCSV(&buf, []string{"filtered", "url"}); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "filtered,url\r\n" +
+			"0,\"http://a.com/%22d%22,e\"\r\n" +
+			"0,http://a.com/b\r\n" +
+			"2,http://a.com/filter\r\n"
+		if actual := buf.String(); expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+	})
+}
+
+func TestSiteReport_WriteCSV(t *testing.T) {
+	t.Parallel()
+
+	r := NewSiteReport(map[string]*Page{
+		"http://a.com": {
+			Links:  []string{"http://a.com/b", `http://a.com/"c"`},
+			Assets: []string{"http://a.com/d.png"},
+		},
+		"http://a.com/b": {
+			Links: []string{"http://a.com"},
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "source,target,kind\r\n" +
+		"http://a.com,http://a.com/b,link\r\n" +
+		"http://a.com,\"http://a.com/\"\"c\"\"\",link\r\n" +
+		"http://a.com,http://a.com/d.png,asset\r\n" +
+		"http://a.com/b,http://a.com,link\r\n"
+	if actual := buf.String(); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
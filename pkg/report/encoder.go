@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Encoder encodes a RecordReport in to a machine-readable format.
+type Encoder interface {
+	Encode(w io.Writer, r *RecordReport) error
+}
+
+// CheckEncoder encodes a CheckReport in to a format understood by CI tools.
+type CheckEncoder interface {
+	Encode(w io.Writer, r *CheckReport) error
+}
+
+// JSONEncoder encodes the report as a single json document.
+type JSONEncoder struct{}
+
+// NewJSONEncoder creates an Encoder for json.
+func NewJSONEncoder() Encoder {
+	return JSONEncoder{}
+}
+
+// Encode writes the report as a json document holding the duration of the
+// crawl along with all the records.
+func (JSONEncoder) Encode(w io.Writer, r *RecordReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(struct {
+		Duration time.Duration `json:"duration"`
+		Records  []Record      `json:"records"`
+	}{
+		Duration: r.duration,
+		Records:  r.records,
+	})
+	return errors.Wrap(err, "unable to encode json")
+}
+
+// NDJSONEncoder encodes the report as newline delimited json, with one record
+// per line.
+type NDJSONEncoder struct{}
+
+// NewNDJSONEncoder creates an Encoder for newline delimited json.
+func NewNDJSONEncoder() Encoder {
+	return NDJSONEncoder{}
+}
+
+// Encode writes each record of the report on a line of it's own.
+func (NDJSONEncoder) Encode(w io.Writer, r *RecordReport) error {
+	enc := json.NewEncoder(w)
+	for _, v := range r.records {
+		if err := enc.Encode(v); err != nil {
+			return errors.Wrap(err, "unable to encode ndjson")
+		}
+	}
+	return nil
+}
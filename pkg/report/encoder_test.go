@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2005, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRecordReport(time.Second, []Record{
+		{
+			URL:        "http://a.com/page",
+			StatusCode: 404,
+			Requested:  1,
+			Errorred:   1,
+			RefLinks:   []string{},
+			RefAssets:  []string{},
+		},
+		{
+			URL:          "http://a.com",
+			StatusCode:   200,
+			ContentType:  "text/html",
+			LastModified: &modified,
+			Requested:    1,
+			Received:     1,
+			Duration:     time.Millisecond,
+			RefLinks:     []string{"http://a.com/page"},
+			RefAssets:    []string{"http://a.com/image.png"},
+			RefKinds:     map[string]string{"http://a.com/image.png": "image"},
+		},
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := NewJSONEncoder().Encode(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+
+		var doc struct {
+			Duration time.Duration `json:"duration"`
+			Records  []Record      `json:"records"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := time.Second, doc.Duration; expected != actual {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+		if expected, actual := r.Records(), doc.Records; !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := NewNDJSONEncoder().Encode(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if expected, actual := 2, len(lines); expected != actual {
+			t.Fatalf("expected: %d, actual: %d", expected, actual)
+		}
+
+		expected := `{"url":"http://a.com","status_code":200,"content_type":"text/html","last_modified":"2005-01-01T12:00:00Z","noindex":false,"requested":1,"received":1,"filtered":0,"errorred":0,"skipped":0,"retried":0,"duration":1000000,"ref_links":["http://a.com/page"],"ref_assets":["http://a.com/image.png"],"ref_kinds":{"http://a.com/image.png":"image"}}`
+		if actual := lines[0]; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+
+		for k, v := range lines {
+			var record Record
+			if err := json.Unmarshal([]byte(v), &record); err != nil {
+				t.Fatal(err)
+			}
+			if expected, actual := r.Records()[k], record; !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const htmlTemplate = "/templates/html.tmpl"
+
+// maxBarWidth is the width in pixels of the largest bar of a chart.
+const maxBarWidth = 300
+
+// HTMLEncoder encodes the report as a single html page, that can be viewed
+// offline. The template is embedded in to the binary, see templates.go.
+type HTMLEncoder struct {
+	local bool
+}
+
+// NewHTMLEncoder creates an Encoder for html. If local is true, the template
+// is read from the file system instead.
+func NewHTMLEncoder(local bool) Encoder {
+	return HTMLEncoder{local}
+}
+
+// Encode writes the report as a html page with sortable tables of the metrics,
+// a listing of the errors and a drill-down of the links of every page.
+func (e HTMLEncoder) Encode(w io.Writer, r *RecordReport) error {
+	src, err := _escFSString(e.local, htmlTemplate)
+	if err != nil {
+		return errors.Wrap(err, "unable to read html template")
+	}
+
+	tmpl, err := template.New("html").Funcs(template.FuncMap{
+		"ms": func(d time.Duration) int64 {
+			return d.Nanoseconds() / 1e6
+		},
+	}).Parse(src)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse html template")
+	}
+
+	if err := tmpl.Execute(w, newHTMLReport(r)); err != nil {
+		return errors.Wrap(err, "unable to execute html template")
+	}
+	return nil
+}
+
+type htmlReport struct {
+	Duration time.Duration
+	Summary  htmlSummary
+	Charts   []htmlChart
+	Pages    []htmlPage
+	Errors   []htmlPage
+	// IDs holds the element id of the drill-down of each page.
+	IDs map[string]string
+}
+
+type htmlSummary struct {
+	URLs, Requested, Received int
+	Filtered, Errorred        int
+	Retried, Skipped          int
+}
+
+type htmlChart struct {
+	Title string
+	Bars  []htmlBar
+}
+
+type htmlBar struct {
+	Label        string
+	Count, Width int
+}
+
+type htmlPage struct {
+	Record
+	ID               string
+	Inbound          []string
+	Outbound, Assets []htmlRef
+}
+
+type htmlRef struct {
+	URL, Kind string
+}
+
+func newHTMLReport(r *RecordReport) htmlReport {
+	res := htmlReport{
+		Duration: r.duration,
+		IDs:      map[string]string{},
+	}
+
+	inbound := map[string][]string{}
+	for k, v := range r.records {
+		res.IDs[v.URL] = fmt.Sprintf("page-%d", k)
+		for _, u := range append(append([]string{}, v.RefLinks...), v.RefAssets...) {
+			inbound[u] = append(inbound[u], v.URL)
+		}
+	}
+
+	var (
+		statuses  = map[string]int{}
+		kinds     = map[string]int{}
+		durations = map[string]int{}
+	)
+	for _, v := range r.records {
+		page := htmlPage{
+			Record:   v,
+			ID:       res.IDs[v.URL],
+			Inbound:  dedupe(inbound[v.URL]),
+			Outbound: refs(v.RefLinks, v.RefKinds),
+			Assets:   refs(v.RefAssets, v.RefKinds),
+		}
+		res.Pages = append(res.Pages, page)
+		if v.Errorred > 0 {
+			res.Errors = append(res.Errors, page)
+		}
+
+		res.Summary.URLs++
+		res.Summary.Requested += v.Requested
+		res.Summary.Received += v.Received
+		res.Summary.Filtered += v.Filtered
+		res.Summary.Errorred += v.Errorred
+		res.Summary.Retried += v.Retried
+		res.Summary.Skipped += v.Skipped
+
+		if v.StatusCode > 0 {
+			statuses[fmt.Sprintf("%dxx", v.StatusCode/100)]++
+		}
+		if v.Received > 0 {
+			durations[durationBucket(v.Duration)]++
+		}
+		for _, u := range v.RefAssets {
+			kinds[v.RefKinds[u]]++
+		}
+	}
+
+	res.Charts = []htmlChart{
+		{"Status Codes", bars(statuses, nil)},
+		{"Response Times", bars(durations, durationBuckets)},
+		{"Asset Kinds", bars(kinds, nil)},
+	}
+	return res
+}
+
+var durationBuckets = []string{"< 100ms", "< 500ms", "< 1s", "< 5s", ">= 5s"}
+
+func durationBucket(d time.Duration) string {
+	switch {
+	case d < 100*time.Millisecond:
+		return durationBuckets[0]
+	case d < 500*time.Millisecond:
+		return durationBuckets[1]
+	case d < time.Second:
+		return durationBuckets[2]
+	case d < 5*time.Second:
+		return durationBuckets[3]
+	default:
+		return durationBuckets[4]
+	}
+}
+
+// bars returns a bar for each of the counts, in the order of the labels. If
+// there are no labels, the bars are sorted by their label.
+func bars(counts map[string]int, labels []string) []htmlBar {
+	if labels == nil {
+		for k := range counts {
+			labels = append(labels, k)
+		}
+		sort.Strings(labels)
+	}
+
+	var max int
+	for _, v := range counts {
+		if v > max {
+			max = v
+		}
+	}
+
+	var res []htmlBar
+	for _, v := range labels {
+		if count, ok := counts[v]; ok {
+			res = append(res, htmlBar{
+				Label: v,
+				Count: count,
+				Width: count * maxBarWidth / max,
+			})
+		}
+	}
+	return res
+}
+
+func refs(urls []string, kinds map[string]string) []htmlRef {
+	res := make([]htmlRef, len(urls))
+	for k, v := range urls {
+		res[k] = htmlRef{v, kinds[v]}
+	}
+	return res
+}
+
+func dedupe(a []string) []string {
+	var res []string
+	for k := range set(a) {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLEncoder(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecordReport(time.Second, []Record{
+		{
+			URL:        "http://a.com",
+			StatusCode: 200,
+			Requested:  1,
+			Received:   1,
+			Duration:   200 * time.Millisecond,
+			RefLinks:   []string{"http://a.com/bad", "http://a.com/<b>"},
+			RefAssets:  []string{"http://a.com/image.png"},
+			RefKinds: map[string]string{
+				"http://a.com/bad":       "anchor",
+				"http://a.com/<b>":       "anchor",
+				"http://a.com/image.png": "image",
+			},
+		},
+		{
+			URL:        "http://a.com/bad",
+			StatusCode: 404,
+			Requested:  1,
+			Errorred:   1,
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := NewHTMLEncoder(false).Encode(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, v := range []string{
+		`<details id="page-0">`,
+		`<details id="page-1">`,
+		`<td><a href="#page-1">http://a.com/bad</a></td>`,
+		`<a href="#page-0">http://a.com</a><br>`,
+		`http://a.com/&lt;b&gt;`,
+		`<label>4xx</label>`,
+		`<label>&lt; 500ms</label>`,
+		`<label>image</label>`,
+	} {
+		if !strings.Contains(out, v) {
+			t.Errorf("expected: %s, actual: %s", v, out)
+		}
+	}
+
+	// The report has to work offline, so nothing can be loaded from elsewhere.
+	for _, v := range []string{"<link", "src="} {
+		if strings.Contains(out, v) {
+			t.Errorf("expected no %s, actual: %s", v, out)
+		}
+	}
+}
+
+func TestHTMLEncoder_Embedded(t *testing.T) {
+	t.Parallel()
+
+	expected, err := ioutil.ReadFile("templates/html.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := _escFSMustByte(false, htmlTemplate); !reflect.DeepEqual(expected, actual) {
+		t.Error("expected embedded template to match templates/html.tmpl, run make pkg/report/templates.go")
+	}
+}
+
+func TestBars(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name     string
+		counts   map[string]int
+		labels   []string
+		expected []htmlBar
+	}{
+		{"empty", map[string]int{}, nil, nil},
+		{"sorted", map[string]int{"b": 1, "a": 2}, nil, []htmlBar{
+			{"a", 2, maxBarWidth},
+			{"b", 1, maxBarWidth / 2},
+		}},
+		{"labels", map[string]int{"b": 1, "a": 2}, []string{"c", "b", "a"}, []htmlBar{
+			{"b", 1, maxBarWidth / 2},
+			{"a", 2, maxBarWidth},
+		}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if actual := bars(v.counts, v.labels); !reflect.DeepEqual(v.expected, actual) {
+				t.Errorf("expected: %v, actual: %v", v.expected, actual)
+			}
+		})
+	}
+}
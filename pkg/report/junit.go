@@ -0,0 +1,109 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// JUnitEncoder encodes the report as JUnit XML, with a testcase for every page
+// that fails when the page has broken refs.
+type JUnitEncoder struct{}
+
+// NewJUnitEncoder creates a CheckEncoder for JUnit XML.
+func NewJUnitEncoder() CheckEncoder {
+	return JUnitEncoder{}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// Encode writes a testsuite for each host of the pages, holding a testcase for
+// each of the pages. The failure of a testcase lists every broken ref of the
+// page.
+func (JUnitEncoder) Encode(w io.Writer, r *CheckReport) error {
+	var (
+		doc    = junitTestSuites{Name: "crwlr"}
+		suites = map[string]int{}
+	)
+	for _, page := range r.Pages() {
+		host := page
+		if u, err := url.Parse(page); err == nil && u.Host != "" {
+			host = u.Host
+		}
+
+		k, ok := suites[host]
+		if !ok {
+			k = len(doc.Suites)
+			suites[host] = k
+			doc.Suites = append(doc.Suites, junitTestSuite{Name: host})
+		}
+
+		var (
+			suite = &doc.Suites[k]
+			c     = junitTestCase{Name: page, ClassName: host}
+			lines []string
+		)
+		for _, v := range r.Refs(page) {
+			if r.Failed(v) {
+				check, _ := r.Check(v)
+				lines = append(lines, fmt.Sprintf("%s (%s)", v, check))
+			}
+		}
+		if len(lines) > 0 {
+			message := fmt.Sprintf("%d broken refs", len(lines))
+			if len(lines) == 1 {
+				message = "1 broken ref"
+			}
+			c.Failure = &junitFailure{
+				Message: message,
+				Type:    "broken",
+				Text:    strings.Join(lines, "\n"),
+			}
+			suite.Failures++
+			doc.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+		doc.Tests++
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Wrap(err, "unable to encode junit")
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return errors.Wrap(err, "unable to encode junit")
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.Wrap(err, "unable to encode junit")
+}
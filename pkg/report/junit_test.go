@@ -0,0 +1,78 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestCheckReport() *CheckReport {
+	return NewCheckReport(map[string][]string{
+		"http://a.com":   {"http://a.com/b", "http://a.com/c", "http://b.com"},
+		"http://a.com/b": {"http://a.com/d.png"},
+		"http://c.com":   {"http://a.com/c"},
+	}, map[string]Check{
+		"http://a.com":       {StatusCode: 200},
+		"http://a.com/b":     {StatusCode: 200},
+		"http://a.com/c":     {StatusCode: 404},
+		"http://a.com/d.png": {StatusCode: 200},
+		"http://b.com":       {Err: "no such host"},
+	})
+}
+
+func TestJUnitEncoder(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := NewJUnitEncoder().Encode(&buf, newTestCheckReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := buf.String(); !strings.HasPrefix(actual, xml.Header) {
+		t.Errorf("expected: %q, actual: %q", xml.Header, actual)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := junitTestSuites{
+		XMLName:  xml.Name{Local: "testsuites"},
+		Name:     "crwlr",
+		Tests:    3,
+		Failures: 2,
+		Suites: []junitTestSuite{
+			{
+				Name:     "a.com",
+				Tests:    2,
+				Failures: 1,
+				Cases: []junitTestCase{
+					{Name: "http://a.com", ClassName: "a.com", Failure: &junitFailure{
+						Message: "2 broken refs",
+						Type:    "broken",
+						Text:    "http://a.com/c (404 Not Found)\nhttp://b.com (no such host)",
+					}},
+					{Name: "http://a.com/b", ClassName: "a.com"},
+				},
+			},
+			{
+				Name:     "c.com",
+				Tests:    1,
+				Failures: 1,
+				Cases: []junitTestCase{
+					{Name: "http://c.com", ClassName: "c.com", Failure: &junitFailure{
+						Message: "1 broken ref",
+						Type:    "broken",
+						Text:    "http://a.com/c (404 Not Found)",
+					}},
+				},
+			},
+		},
+	}
+	if actual := doc; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %+v, actual: %+v", expected, actual)
+	}
+}
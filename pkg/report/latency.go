@@ -0,0 +1,81 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Latency is the time spent in each phase of a request.
+type Latency struct {
+	DNS     time.Duration `json:"dns,omitempty"`
+	Connect time.Duration `json:"connect,omitempty"`
+	TLS     time.Duration `json:"tls,omitempty"`
+	TTFB    time.Duration `json:"ttfb,omitempty"`
+	Body    time.Duration `json:"body,omitempty"`
+	Total   time.Duration `json:"total"`
+	Bytes   int64         `json:"bytes"`
+}
+
+// latencyPhases are the phases of a request, in the order they happen.
+var latencyPhases = []struct {
+	name string
+	fn   func(Latency) time.Duration
+}{
+	{"DNS", func(l Latency) time.Duration { return l.DNS }},
+	{"Connect", func(l Latency) time.Duration { return l.Connect }},
+	{"TLS", func(l Latency) time.Duration { return l.TLS }},
+	{"TTFB", func(l Latency) time.Duration { return l.TTFB }},
+	{"Body", func(l Latency) time.Duration { return l.Body }},
+	{"Total", func(l Latency) time.Duration { return l.Total }},
+}
+
+// Percentiles summarises the distribution of a series of durations.
+type Percentiles struct {
+	P50, P90, P99, Max time.Duration
+}
+
+// NewPercentiles creates the Percentiles of the durations, using the nearest
+// rank.
+func NewPercentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	rank := func(p float64) time.Duration {
+		n := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if n < 1 {
+			n = 1
+		}
+		return sorted[n-1]
+	}
+	return Percentiles{
+		P50: rank(50),
+		P90: rank(90),
+		P99: rank(99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+// latencyPercentiles returns the Percentiles of a phase of the latencies.
+func latencyPercentiles(latencies []Latency, phase func(Latency) time.Duration) Percentiles {
+	durations := make([]time.Duration, len(latencies))
+	for k, v := range latencies {
+		durations[k] = phase(v)
+	}
+	return NewPercentiles(durations)
+}
+
+// latencyBytes returns the total bytes of the latencies.
+func latencyBytes(latencies []Latency) int64 {
+	var res int64
+	for _, v := range latencies {
+		res += v.Bytes
+	}
+	return res
+}
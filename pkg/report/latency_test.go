@@ -0,0 +1,51 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPercentiles(t *testing.T) {
+	t.Parallel()
+
+	durations := func(n int) []time.Duration {
+		res := make([]time.Duration, n)
+		for k := range res {
+			// Reverse the order, to make sure the durations are sorted.
+			res[k] = time.Duration(n-k) * time.Millisecond
+		}
+		return res
+	}
+
+	for _, v := range []struct {
+		name      string
+		durations []time.Duration
+		expected  Percentiles
+	}{
+		{"empty", nil, Percentiles{}},
+		{"one", durations(1), Percentiles{time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond}},
+		{"ten", durations(10), Percentiles{5 * time.Millisecond, 9 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond}},
+		{"hundred", durations(100), Percentiles{50 * time.Millisecond, 90 * time.Millisecond, 99 * time.Millisecond, 100 * time.Millisecond}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if expected, actual := v.expected, NewPercentiles(v.durations); expected != actual {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestRow_Latencies(t *testing.T) {
+	t.Parallel()
+
+	r := &Row{}
+	r.Add(&Row{Latencies: []Latency{{Total: time.Second, Bytes: 10}}})
+	r.Add(&Row{Latencies: []Latency{{Total: 3 * time.Second, Bytes: 20}, {Total: 2 * time.Second, Bytes: 30}}})
+
+	if expected, actual := (Percentiles{2 * time.Second, 3 * time.Second, 3 * time.Second, 3 * time.Second}), r.Percentiles(); expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := int64(60), r.Bytes(); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
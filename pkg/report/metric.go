@@ -9,13 +9,18 @@ import (
 
 // MetricReport creates a report about the mertics of a crawl
 type MetricReport struct {
+	ordering
 	duration time.Duration
 	rows     map[string]*Row
 }
 
 // NewMetricReport generates a report from the cache including a duration
 func NewMetricReport(duration time.Duration, rows map[string]*Row) *MetricReport {
-	return &MetricReport{duration, rows}
+	return &MetricReport{
+		ordering: ordering{by: SortURL},
+		duration: duration,
+		rows:     rows,
+	}
 }
 
 func (r *MetricReport) Write(w io.Writer) error {
@@ -24,30 +29,99 @@ func (r *MetricReport) Write(w io.Writer) error {
 		return err
 	}
 
-	fmt.Fprintln(w, " URL\t Avg Duration (ms)\t Requested\t Received\t Filtered\t Errorred\t")
-	for k, v := range rows {
-		fmt.Fprintf(w, " %s\t %d\t %d\t %d\t %d\t %d\t\n",
+	var latencies []Latency
+	for _, v := range rows {
+		latencies = append(latencies, v.Latencies...)
+	}
+
+	fmt.Fprintln(w, " URL\t Avg Duration (ms)\t P50 (ms)\t P90 (ms)\t P99 (ms)\t Max (ms)\t Bytes\t Requested\t Received\t Filtered\t Errorred\t Retried\t Skipped\t")
+	for _, k := range r.orderRows(rows) {
+		var (
+			v = rows[k]
+			p = v.Percentiles()
+		)
+		fmt.Fprintf(w, " %s\t %d\t %d\t %d\t %d\t %d\t %d\t %d\t %d\t %d\t %d\t %d\t %d\t\n",
 			k,
 			v.Duration.Nanoseconds()/1e6,
+			p.P50.Nanoseconds()/1e6,
+			p.P90.Nanoseconds()/1e6,
+			p.P99.Nanoseconds()/1e6,
+			p.Max.Nanoseconds()/1e6,
+			v.Bytes(),
 			v.Requested,
 			v.Received,
 			v.Filtered,
 			v.Errorred,
+			v.Retried,
+			v.Skipped,
 		)
 	}
 
 	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, " Totals\t Duration (ms)\t")
-	fmt.Fprintf(w, " \t %d\t\n", r.duration.Nanoseconds()/1e6)
+	fmt.Fprintln(w, " Phase\t P50 (ms)\t P90 (ms)\t P99 (ms)\t Max (ms)\t")
+	for _, v := range latencyPhases {
+		p := latencyPercentiles(latencies, v.fn)
+		fmt.Fprintf(w, " %s\t %d\t %d\t %d\t %d\t\n",
+			v.name,
+			p.P50.Nanoseconds()/1e6,
+			p.P90.Nanoseconds()/1e6,
+			p.P99.Nanoseconds()/1e6,
+			p.Max.Nanoseconds()/1e6,
+		)
+	}
+
+	bytes := latencyBytes(latencies)
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, " Totals\t Duration (ms)\t Bytes\t Throughput (KB/s)\t")
+	fmt.Fprintf(w, " \t %d\t %d\t %.1f\t\n", r.duration.Nanoseconds()/1e6, bytes, throughput(bytes, r.duration))
 
 	return nil
 }
 
+// throughput returns the kilobytes per second of downloading the bytes over
+// the duration.
+func throughput(bytes int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(bytes) / 1024 / d.Seconds()
+}
+
+// orderRows returns the urls of the rows in the order of the report.
+func (r *MetricReport) orderRows(rows map[string]*Row) []string {
+	keys := make(map[string]sortKey, len(rows))
+	for k, v := range rows {
+		keys[k] = sortKey{
+			duration: v.Duration,
+			errors:   v.Errorred,
+			inbound:  v.Inbound,
+		}
+	}
+	return r.order(keys)
+}
+
 // Row is used for metric reporting
 type Row struct {
 	Requested, Received     int
 	Filtered, Errorred      int
+	Skipped, Retried        int
 	TotalDuration, Duration time.Duration
+	// Inbound is the number of links to the url.
+	Inbound int
+	// Latencies holds the latency of every request of the url.
+	Latencies []Latency
+}
+
+// Percentiles returns the Percentiles of the total latency of the requests.
+func (c *Row) Percentiles() Percentiles {
+	return latencyPercentiles(c.Latencies, func(l Latency) time.Duration {
+		return l.Total
+	})
+}
+
+// Bytes returns the number of bytes downloaded by the requests.
+func (c *Row) Bytes() int64 {
+	return latencyBytes(c.Latencies)
 }
 
 // Add sums metrics to the column data
@@ -56,6 +130,10 @@ func (c *Row) Add(m *Row) {
 	c.Received += m.Received
 	c.Filtered += m.Filtered
 	c.Errorred += m.Errorred
+	c.Skipped += m.Skipped
+	c.Retried += m.Retried
+	c.Inbound += m.Inbound
+	c.Latencies = append(c.Latencies, m.Latencies...)
 
 	c.TotalDuration += m.Duration
 	c.Duration = c.TotalDuration
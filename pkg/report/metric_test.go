@@ -1,6 +1,8 @@
 package report
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 )
@@ -18,6 +20,7 @@ func TestRow(t *testing.T) {
 		r.Add(&Row{
 			Received: 1,
 			Errorred: 1,
+			Skipped:  1,
 			Duration: time.Second * 3,
 		})
 
@@ -27,6 +30,9 @@ func TestRow(t *testing.T) {
 		if r.Errorred != 1 {
 			t.Errorf("expected: %d, actual: %d", 1, r.Errorred)
 		}
+		if r.Skipped != 1 {
+			t.Errorf("expected: %d, actual: %d", 1, r.Skipped)
+		}
 		if r.Duration != time.Second*2 {
 			t.Errorf("expected: %d, actual: %d", time.Second*2, r.Duration)
 		}
@@ -118,3 +124,38 @@ func TestAggregation_Row(t *testing.T) {
 		}
 	})
 }
+
+func TestMetricReport_Write(t *testing.T) {
+	t.Parallel()
+
+	r := NewMetricReport(time.Second, map[string]*Row{
+		"http://a.com/c": {Received: 1, Duration: time.Millisecond},
+		"http://a.com/a": {Received: 1, Duration: 3 * time.Millisecond},
+		"http://a.com/b": {Received: 1, Duration: 2 * time.Millisecond},
+	})
+
+	urls := func() string {
+		var buf bytes.Buffer
+		if err := r.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var res []string
+		for _, v := range strings.Split(buf.String(), "\n")[1:] {
+			if fields := strings.Fields(v); len(fields) > 0 && strings.HasPrefix(fields[0], "http") {
+				res = append(res, fields[0])
+			}
+		}
+		return strings.Join(res, ",")
+	}
+
+	if expected, actual := "http://a.com/a,http://a.com/b,http://a.com/c", urls(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+
+	r.Sort(SortDuration, false)
+	r.Limit(2)
+	if expected, actual := "http://a.com/c,http://a.com/b", urls(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
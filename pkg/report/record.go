@@ -0,0 +1,59 @@
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// Record holds every metric of a url of the crawl.
+type Record struct {
+	URL              string            `json:"url"`
+	StatusCode       int               `json:"status_code,omitempty"`
+	ContentType      string            `json:"content_type,omitempty"`
+	ContentLength    int64             `json:"content_length,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+	Redirects        []Redirect        `json:"redirects,omitempty"`
+	Canonical        string            `json:"canonical,omitempty"`
+	LastModified     *time.Time        `json:"last_modified,omitempty"`
+	NoIndex          bool              `json:"noindex"`
+	Requested        int               `json:"requested"`
+	Received         int               `json:"received"`
+	Filtered         int               `json:"filtered"`
+	Errorred         int               `json:"errorred"`
+	Skipped          int               `json:"skipped"`
+	Retried          int               `json:"retried"`
+	Duration         time.Duration     `json:"duration"`
+	Latencies        []Latency         `json:"latencies,omitempty"`
+	RefLinks         []string          `json:"ref_links"`
+	RefAssets        []string          `json:"ref_assets"`
+	RefExternalLinks []string          `json:"ref_external_links,omitempty"`
+	RefRejectedLinks []string          `json:"ref_rejected_links,omitempty"`
+	RefNofollowLinks []string          `json:"ref_nofollow_links,omitempty"`
+	RefKinds         map[string]string `json:"ref_kinds,omitempty"`
+}
+
+// RecordReport creates a report of every metric of every url of a crawl, so
+// the crawl can be encoded in to a machine-readable format.
+type RecordReport struct {
+	duration time.Duration
+	records  []Record
+}
+
+// NewRecordReport generates a report from the records, sorted by their url.
+func NewRecordReport(duration time.Duration, records []Record) *RecordReport {
+	res := append([]Record{}, records...)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].URL < res[j].URL
+	})
+	return &RecordReport{duration, res}
+}
+
+// Duration returns the duration of the crawl.
+func (r *RecordReport) Duration() time.Duration {
+	return r.duration
+}
+
+// Records returns the records of the crawl.
+func (r *RecordReport) Records() []Record {
+	return r.records
+}
@@ -0,0 +1,127 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifRuleBrokenRef is the rule broken by a page that references a url
+	// that can't be reached.
+	sarifRuleBrokenRef = "broken-ref"
+)
+
+// SARIFEncoder encodes the report as SARIF, the static analysis results format
+// understood by code scanning tools, with a result for every broken ref of
+// every page.
+type SARIFEncoder struct {
+	version string
+}
+
+// NewSARIFEncoder creates a CheckEncoder for SARIF, reporting the version of
+// crwlr that checked the pages.
+func NewSARIFEncoder(version string) CheckEncoder {
+	return SARIFEncoder{version}
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Encode writes a single run, with a result located at the page for every
+// broken ref of the page.
+func (e SARIFEncoder) Encode(w io.Writer, r *CheckReport) error {
+	results := []sarifResult{}
+	for _, page := range r.Pages() {
+		for _, v := range r.Refs(page) {
+			if !r.Failed(v) {
+				continue
+			}
+			check, _ := r.Check(v)
+			results = append(results, sarifResult{
+				RuleID:  sarifRuleBrokenRef,
+				Level:   "error",
+				Message: sarifMessage{fmt.Sprintf("%s is broken (%s)", v, check)},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{page},
+					},
+				}},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:           "crwlr",
+					Version:        e.version,
+					InformationURI: "https://github.com/SimonRichardson/crwlr",
+					Rules: []sarifRule{{
+						ID:               sarifRuleBrokenRef,
+						ShortDescription: sarifMessage{"A link or asset of the page can't be reached"},
+					}},
+				},
+			},
+			Results: results,
+		}},
+	})
+	return errors.Wrap(err, "unable to encode sarif")
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSARIFEncoder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("results", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := NewSARIFEncoder("1.0").Encode(&buf, newTestCheckReport()); err != nil {
+			t.Fatal(err)
+		}
+
+		var doc sarifLog
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := "2.1.0", doc.Version; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+		if expected, actual := 1, len(doc.Runs); expected != actual {
+			t.Fatalf("expected: %d, actual: %d", expected, actual)
+		}
+
+		run := doc.Runs[0]
+		if expected, actual := "1.0", run.Tool.Driver.Version; expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+
+		type result struct {
+			page, message string
+		}
+		var actual []result
+		for _, v := range run.Results {
+			if expected, actual := sarifRuleBrokenRef, v.RuleID; expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+			actual = append(actual, result{v.Locations[0].PhysicalLocation.ArtifactLocation.URI, v.Message.Text})
+		}
+		expected := []result{
+			{"http://a.com", "http://a.com/c is broken (404 Not Found)"},
+			{"http://a.com", "http://b.com is broken (no such host)"},
+			{"http://c.com", "http://a.com/c is broken (404 Not Found)"},
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		r := newTestCheckReport()
+		r.Allow(NewAllowlist([]string{"http://a.com/c", "http://b.com"}))
+
+		var buf bytes.Buffer
+		if err := NewSARIFEncoder("1.0").Encode(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+
+		var doc sarifLog
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if actual := doc.Runs[0].Results; actual == nil || len(actual) != 0 {
+			t.Errorf("expected: [], actual: %v", actual)
+		}
+	})
+}
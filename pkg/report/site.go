@@ -5,16 +5,22 @@ import (
 	"io"
 	"math"
 	"net/url"
+	"sort"
+	"time"
 )
 
 // SiteReport creates a report about a page of the crawl
 type SiteReport struct {
+	ordering
 	pages map[string]*Page
 }
 
 // NewSiteReport generates a report from the cache
 func NewSiteReport(pages map[string]*Page) *SiteReport {
-	return &SiteReport{pages}
+	return &SiteReport{
+		ordering: ordering{by: SortURL},
+		pages:    pages,
+	}
 }
 
 func (r *SiteReport) Write(w io.Writer) error {
@@ -23,13 +29,18 @@ func (r *SiteReport) Write(w io.Writer) error {
 		return err
 	}
 
-	fmt.Fprintln(w, " URL\t Ref Links\t Ref Assets\t")
-	for k, v := range pages {
-		fmt.Fprintf(w, " %s\t \t \t\n", k)
+	fmt.Fprintln(w, " URL\t Ref Links\t Ref Assets\t Kind\t")
+	for _, k := range r.orderPages(pages) {
+		v := pages[k]
+		if v.NoIndex {
+			k += " (noindex)"
+		}
+		fmt.Fprintf(w, " %s\t \t \t \t\n", k)
 
 		var (
+			assets     = v.GroupedAssets()
 			linkTotal  = len(v.Links)
-			assetTotal = len(v.Assets)
+			assetTotal = len(assets)
 			max        = int(math.Max(float64(linkTotal), float64(assetTotal)))
 			rows       = make([]*row, max)
 		)
@@ -40,33 +51,93 @@ func (r *SiteReport) Write(w io.Writer) error {
 				r.Link = v.Links[i]
 			}
 			if i < assetTotal {
-				r.Asset = v.Assets[i]
+				r.Asset = assets[i]
+				r.Kind = v.Kinds[assets[i]]
 			}
 			rows[i] = r
 		}
 
 		for _, v := range rows {
-			fmt.Fprintf(w, " \t %s\t %s\t\n", v.Link, v.Asset)
+			fmt.Fprintf(w, " \t %s\t %s\t %s\t\n", v.Link, v.Asset, v.Kind)
 		}
 	}
 
 	return nil
 }
 
+// orderPages returns the urls of the pages in the order of the report. The
+// inbound links of a page are counted from the links of the other pages.
+func (r *SiteReport) orderPages(pages map[string]*Page) []string {
+	inbound := map[string]int{}
+	for _, v := range pages {
+		for _, link := range v.Links {
+			inbound[normalize(link)]++
+		}
+	}
+
+	keys := make(map[string]sortKey, len(pages))
+	for k, v := range pages {
+		keys[k] = sortKey{
+			duration: v.Duration,
+			errors:   v.Errorred,
+			inbound:  inbound[k],
+		}
+	}
+	return r.order(keys)
+}
+
 type row struct {
-	Link, Asset string
+	Link, Asset, Kind string
 }
 
 // Page records the state of a page
 type Page struct {
 	Links  []string
 	Assets []string
+	// Kinds holds the kind of each of the links and assets, for example
+	// "anchor", "image" or "script".
+	Kinds map[string]string
+	// NoIndex is true if the page asked not to be indexed.
+	NoIndex bool
+	// Duration is how long the page took to request, averaged over the pages
+	// that were added together.
+	Duration, TotalDuration time.Duration
+	// Received is how many times the page was received.
+	Received int
+	// Errorred is how many times requesting the page errorred.
+	Errorred int
 }
 
-// Add sums pages together
+// Add sums pages together, averaging the duration
 func (p *Page) Add(o *Page) {
 	p.Links = append(p.Links, o.Links...)
 	p.Assets = append(p.Assets, o.Assets...)
+	p.NoIndex = p.NoIndex || o.NoIndex
+	p.Received += o.Received
+	p.Errorred += o.Errorred
+
+	p.TotalDuration += o.Duration
+	p.Duration = p.TotalDuration
+	// Prevent division by zero
+	if p.Received > 0 {
+		p.Duration = p.TotalDuration / time.Duration(p.Received)
+	}
+	if len(o.Kinds) > 0 && p.Kinds == nil {
+		p.Kinds = map[string]string{}
+	}
+	for k, v := range o.Kinds {
+		p.Kinds[k] = v
+	}
+}
+
+// GroupedAssets returns the assets of the page grouped by their kind, the
+// order of the assets with in a kind is left untouched.
+func (p *Page) GroupedAssets() []string {
+	res := append([]string{}, p.Assets...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return p.Kinds[res[i]] < p.Kinds[res[j]]
+	})
+	return res
 }
 
 // Aggregate, takes a cache and removes any possible duplication and aggregates
@@ -97,3 +168,15 @@ func aggregatePages(c map[string]*Page) (map[string]*Page, error) {
 
 	return m, nil
 }
+
+// normalize removes the parameters and hashes of a url, in the same way as the
+// pages are aggregated.
+func normalize(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -1,6 +1,38 @@
 package report
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("add", func(t *testing.T) {
+		p := &Page{}
+
+		p.Add(&Page{
+			Received: 1,
+			Duration: time.Second,
+		})
+		p.Add(&Page{
+			Received: 1,
+			Errorred: 1,
+			Duration: time.Second * 3,
+		})
+
+		if p.Received != 2 {
+			t.Errorf("expected: %d, actual: %d", 2, p.Received)
+		}
+		if p.Errorred != 1 {
+			t.Errorf("expected: %d, actual: %d", 1, p.Errorred)
+		}
+		if p.Duration != time.Second*2 {
+			t.Errorf("expected: %d, actual: %d", time.Second*2, p.Duration)
+		}
+	})
+}
 
 func TestAggregation_Page(t *testing.T) {
 	t.Parallel()
@@ -61,3 +93,46 @@ func TestAggregation_Page(t *testing.T) {
 		}
 	})
 }
+
+func TestPage_GroupedAssets(t *testing.T) {
+	t.Parallel()
+
+	p := &Page{
+		Assets: []string{"a.png", "a.js", "b.png", "a.css"},
+		Kinds: map[string]string{
+			"a.png": "image",
+			"b.png": "image",
+			"a.js":  "script",
+			"a.css": "stylesheet",
+		},
+	}
+
+	expected := []string{"a.png", "b.png", "a.js", "a.css"}
+	if actual := p.GroupedAssets(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestSiteReport_Sort(t *testing.T) {
+	t.Parallel()
+
+	r := NewSiteReport(map[string]*Page{
+		"http://a.com":   {Links: []string{"http://a.com/b", "http://a.com/c?d=1"}},
+		"http://a.com/b": {Links: []string{"http://a.com/c"}},
+		"http://a.com/c": {Errorred: 1},
+	})
+	pages, err := aggregatePages(r.pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, actual := []string{"http://a.com", "http://a.com/b", "http://a.com/c"}, r.orderPages(pages); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	r.Sort(SortInbound, true)
+	r.Limit(2)
+	if expected, actual := []string{"http://a.com/c", "http://a.com/b"}, r.orderPages(pages); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
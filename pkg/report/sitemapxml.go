@@ -0,0 +1,151 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxSitemapURLs is the maximum number of urls a sitemap can hold, as defined
+// by the sitemaps protocol.
+const MaxSitemapURLs = 50000
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapURL is a page with in a SitemapXML.
+type SitemapURL struct {
+	Loc string
+	// LastMod is when the page was last modified, it's left out of the sitemap
+	// if it's zero.
+	LastMod time.Time
+}
+
+// SitemapXML creates a sitemap.xml of the pages of the crawl.
+type SitemapXML struct {
+	urls  []SitemapURL
+	limit int
+}
+
+// NewSitemapXML creates a SitemapXML from the pages, sorted by their url.
+func NewSitemapXML(urls []SitemapURL) *SitemapXML {
+	res := append([]SitemapURL{}, urls...)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Loc < res[j].Loc
+	})
+	return &SitemapXML{
+		urls:  res,
+		limit: MaxSitemapURLs,
+	}
+}
+
+// Write writes the pages as a single sitemap. If there are more pages than a
+// sitemap can hold, then WriteFiles should be used instead.
+func (r *SitemapXML) Write(w io.Writer) error {
+	if len(r.urls) > r.limit {
+		return errors.Errorf("%d urls exceeds the sitemap limit of %d", len(r.urls), r.limit)
+	}
+	return writeXML(w, newURLSet(r.urls))
+}
+
+// WriteFiles writes the sitemap to the file at path. If there are more pages
+// than a sitemap can hold, the pages are split in to numbered sitemaps next to
+// the file (sitemap-1.xml, sitemap-2.xml, etc) and the file becomes a sitemap
+// index of them. The locations of the numbered sitemaps are resolved against
+// the base url.
+func (r *SitemapXML) WriteFiles(path string, base *url.URL) error {
+	if len(r.urls) <= r.limit {
+		return writeXMLFile(path, newURLSet(r.urls))
+	}
+
+	var (
+		ext   = filepath.Ext(path)
+		name  = strings.TrimSuffix(filepath.Base(path), ext)
+		index = sitemapIndex{XMLNS: sitemapXMLNS}
+	)
+	for k := 0; k*r.limit < len(r.urls); k++ {
+		end := (k + 1) * r.limit
+		if end > len(r.urls) {
+			end = len(r.urls)
+		}
+
+		file := fmt.Sprintf("%s-%d%s", name, k+1, ext)
+		if err := writeXMLFile(filepath.Join(filepath.Dir(path), file), newURLSet(r.urls[k*r.limit:end])); err != nil {
+			return err
+		}
+
+		loc := file
+		if base != nil {
+			loc = base.ResolveReference(&url.URL{Path: file}).String()
+		}
+		index.Sitemaps = append(index.Sitemaps, sitemapLoc{Loc: loc})
+	}
+	return writeXMLFile(path, index)
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+func newURLSet(urls []SitemapURL) urlSet {
+	res := urlSet{
+		XMLNS: sitemapXMLNS,
+		URLs:  make([]sitemapURL, len(urls)),
+	}
+	for k, v := range urls {
+		res.URLs[k].Loc = v.Loc
+		if !v.LastMod.IsZero() {
+			res.URLs[k].LastMod = v.LastMod.UTC().Format(time.RFC3339)
+		}
+	}
+	return res
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return errors.Wrap(err, "unable to encode sitemap")
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func writeXMLFile(path string, v interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "unable to create sitemap")
+	}
+	if err := writeXML(file, v); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
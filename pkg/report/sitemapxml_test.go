@@ -0,0 +1,93 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemapXML(t *testing.T) {
+	t.Parallel()
+
+	urls := []SitemapURL{
+		{Loc: "http://a.com/b"},
+		{Loc: "http://a.com/", LastMod: time.Date(2005, 1, 1, 12, 0, 0, 0, time.FixedZone("", 3600))},
+		{Loc: "http://a.com/c?d=1&e=2"},
+	}
+
+	t.Run("write", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := NewSitemapXML(urls).Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://a.com/</loc>
+    <lastmod>2005-01-01T11:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>http://a.com/b</loc>
+  </url>
+  <url>
+    <loc>http://a.com/c?d=1&amp;e=2</loc>
+  </url>
+</urlset>
+`
+		if actual := buf.String(); expected != actual {
+			t.Errorf("expected: %s, actual: %s", expected, actual)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		r := NewSitemapXML(urls)
+		r.limit = 2
+
+		if err := r.Write(ioutil.Discard); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("write files", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sitemap")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		base, _ := url.Parse("http://a.com/")
+
+		r := NewSitemapXML(urls)
+		r.limit = 2
+		if err := r.WriteFiles(filepath.Join(dir, "sitemap.xml"), base); err != nil {
+			t.Fatal(err)
+		}
+
+		for file, contains := range map[string][]string{
+			"sitemap.xml": {
+				"<sitemapindex",
+				"<loc>http://a.com/sitemap-1.xml</loc>",
+				"<loc>http://a.com/sitemap-2.xml</loc>",
+			},
+			"sitemap-1.xml": {"<loc>http://a.com/</loc>", "<loc>http://a.com/b</loc>"},
+			"sitemap-2.xml": {"<loc>http://a.com/c?d=1&amp;e=2</loc>"},
+		} {
+			b, err := ioutil.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range contains {
+				if !strings.Contains(string(b), v) {
+					t.Errorf("expected: %s, actual: %s", v, fmt.Sprintf("%s: %s", file, b))
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SortBy defines what the rows of a report are sorted by.
+type SortBy string
+
+const (
+	// SortURL sorts the rows by their url.
+	SortURL SortBy = "url"
+	// SortDuration sorts the rows by how long the url took to request.
+	SortDuration SortBy = "duration"
+	// SortErrors sorts the rows by how many times the url errorred.
+	SortErrors SortBy = "errors"
+	// SortInbound sorts the rows by how many links there are to the url.
+	SortInbound SortBy = "inbound"
+)
+
+// ParseSortBy returns the SortBy for the name.
+func ParseSortBy(name string) (SortBy, error) {
+	switch by := SortBy(strings.ToLower(name)); by {
+	case SortURL, SortDuration, SortErrors, SortInbound:
+		return by, nil
+	default:
+		return "", errors.Errorf("%s: unsupported sort (expected url, duration, errors or inbound)", name)
+	}
+}
+
+// ordering controls the order and number of rows of a report. By default the
+// rows are sorted by their url, in ascending order.
+type ordering struct {
+	by         SortBy
+	descending bool
+	limit      int
+}
+
+// Sort sets what the rows of the report are sorted by. Rows that are equal are
+// then sorted by their url, so the order is the same between runs.
+func (o *ordering) Sort(by SortBy, descending bool) {
+	o.by = by
+	o.descending = descending
+}
+
+// Limit sets the maximum number of rows of the report, taken after sorting.
+// Zero is unlimited.
+func (o *ordering) Limit(n int) {
+	o.limit = n
+}
+
+type sortKey struct {
+	duration        time.Duration
+	errors, inbound int
+}
+
+// order returns the urls of the keys in the order of the report.
+func (o ordering) order(keys map[string]sortKey) []string {
+	urls := make([]string, 0, len(keys))
+	for k := range keys {
+		urls = append(urls, k)
+	}
+
+	less := func(a, b string) bool {
+		x, y := keys[a], keys[b]
+		switch o.by {
+		case SortDuration:
+			if x.duration != y.duration {
+				return x.duration < y.duration
+			}
+		case SortErrors:
+			if x.errors != y.errors {
+				return x.errors < y.errors
+			}
+		case SortInbound:
+			if x.inbound != y.inbound {
+				return x.inbound < y.inbound
+			}
+		}
+		return a < b
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if o.descending {
+			return less(urls[j], urls[i])
+		}
+		return less(urls[i], urls[j])
+	})
+
+	if o.limit > 0 && o.limit < len(urls) {
+		urls = urls[:o.limit]
+	}
+	return urls
+}
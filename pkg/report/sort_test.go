@@ -0,0 +1,71 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSortBy(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name     string
+		expected SortBy
+		err      bool
+	}{
+		{"url", SortURL, false},
+		{"Duration", SortDuration, false},
+		{"errors", SortErrors, false},
+		{"inbound", SortInbound, false},
+		{"bad", "", true},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			actual, err := ParseSortBy(v.name)
+			if expected, actual := v.err, err != nil; expected != actual {
+				t.Fatalf("expected: %t, actual: %t (%v)", expected, actual, err)
+			}
+			if expected := v.expected; expected != actual {
+				t.Errorf("expected: %v, actual: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	t.Parallel()
+
+	keys := map[string]sortKey{
+		"c": {duration: time.Second, errors: 1, inbound: 3},
+		"a": {duration: time.Minute, errors: 0, inbound: 3},
+		"b": {duration: time.Millisecond, errors: 2, inbound: 1},
+		"d": {duration: time.Second, errors: 0, inbound: 0},
+	}
+
+	for _, v := range []struct {
+		name       string
+		by         SortBy
+		descending bool
+		limit      int
+		expected   []string
+	}{
+		{"default", "", false, 0, []string{"a", "b", "c", "d"}},
+		{"url descending", SortURL, true, 0, []string{"d", "c", "b", "a"}},
+		{"duration", SortDuration, false, 0, []string{"b", "c", "d", "a"}},
+		{"duration descending", SortDuration, true, 0, []string{"a", "d", "c", "b"}},
+		{"errors", SortErrors, true, 0, []string{"b", "c", "d", "a"}},
+		{"inbound", SortInbound, true, 0, []string{"c", "a", "b", "d"}},
+		{"top", SortDuration, true, 2, []string{"a", "d"}},
+		{"top past rows", SortURL, false, 10, []string{"a", "b", "c", "d"}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			var o ordering
+			o.Sort(v.by, v.descending)
+			o.Limit(v.limit)
+
+			if actual := o.order(keys); !reflect.DeepEqual(v.expected, actual) {
+				t.Errorf("expected: %v, actual: %v", v.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Redirect is a hop of a redirect chain.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// Status records the response of a url.
+type Status struct {
+	// StatusCode is the status code of the final response, zero if there was
+	// no response, for example when the host couldn't be found.
+	StatusCode int
+	Redirects  []Redirect
+}
+
+// Class returns the class of the status code (2xx, 3xx etc), or "none" if
+// there was no response.
+func (s *Status) Class() string {
+	if s.StatusCode <= 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%dxx", s.StatusCode/100)
+}
+
+// Loop returns true if the redirects lead back to a url of the chain.
+func (s *Status) Loop() bool {
+	seen := map[string]struct{}{}
+	for _, v := range s.Redirects {
+		seen[v.URL] = struct{}{}
+		if _, ok := seen[v.Location]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusReport counts the urls of a crawl by the class of their status code
+// and flags the redirect chains that are too long or that loop.
+type StatusReport struct {
+	statuses map[string]*Status
+	maxHops  int
+}
+
+// NewStatusReport generates a report from the statuses, flagging redirect
+// chains with more than maxHops redirects.
+func NewStatusReport(statuses map[string]*Status, maxHops int) *StatusReport {
+	return &StatusReport{statuses, maxHops}
+}
+
+// Classes returns the number of urls for each class of status code.
+func (r *StatusReport) Classes() map[string]int {
+	res := map[string]int{}
+	for _, v := range r.statuses {
+		res[v.Class()]++
+	}
+	return res
+}
+
+// Flagged returns the urls with a redirect chain that's too long or that
+// loops, sorted by their url.
+func (r *StatusReport) Flagged() []string {
+	var res []string
+	for k, v := range r.statuses {
+		if len(v.Redirects) > r.maxHops || v.Loop() {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (r *StatusReport) Write(w io.Writer) error {
+	classes := r.Classes()
+	names := make([]string, 0, len(classes))
+	for k := range classes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, " Status\t URLs\t")
+	for _, v := range names {
+		fmt.Fprintf(w, " %s\t %d\t\n", v, classes[v])
+	}
+
+	flagged := r.Flagged()
+	if len(flagged) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, " URL\t Hops\t Loop\t Chain\t")
+	for _, k := range flagged {
+		v := r.statuses[k]
+
+		chain := make([]string, 0, len(v.Redirects)+1)
+		for _, hop := range v.Redirects {
+			chain = append(chain, fmt.Sprintf("%s (%d)", hop.URL, hop.StatusCode))
+		}
+		if n := len(v.Redirects); n > 0 {
+			chain = append(chain, v.Redirects[n-1].Location)
+		}
+
+		fmt.Fprintf(w, " %s\t %d\t %t\t %s\t\n", k, len(v.Redirects), v.Loop(), strings.Join(chain, " -> "))
+	}
+	return nil
+}
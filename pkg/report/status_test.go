@@ -0,0 +1,99 @@
+package report
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name  string
+		s     Status
+		class string
+		loop  bool
+	}{
+		{"ok", Status{StatusCode: 200}, "2xx", false},
+		{"no response", Status{}, "none", false},
+		{"redirect", Status{StatusCode: 200, Redirects: []Redirect{
+			{"http://a.com/a", 301, "http://a.com/b"},
+			{"http://a.com/b", 302, "http://a.com/c"},
+		}}, "2xx", false},
+		{"loop", Status{Redirects: []Redirect{
+			{"http://a.com/a", 301, "http://a.com/b"},
+			{"http://a.com/b", 301, "http://a.com/a"},
+		}}, "none", true},
+		{"self", Status{Redirects: []Redirect{
+			{"http://a.com/a", 302, "http://a.com/a"},
+		}}, "none", true},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			if expected, actual := v.class, v.s.Class(); expected != actual {
+				t.Errorf("expected: %s, actual: %s", expected, actual)
+			}
+			if expected, actual := v.loop, v.s.Loop(); expected != actual {
+				t.Errorf("expected: %t, actual: %t", expected, actual)
+			}
+		})
+	}
+}
+
+func TestStatusReport(t *testing.T) {
+	t.Parallel()
+
+	r := NewStatusReport(map[string]*Status{
+		"http://a.com":     {StatusCode: 200},
+		"http://a.com/404": {StatusCode: 404},
+		"http://a.com/dns": {},
+		"http://a.com/one": {StatusCode: 200, Redirects: []Redirect{
+			{"http://a.com/one", 301, "http://a.com"},
+		}},
+		"http://a.com/two": {StatusCode: 200, Redirects: []Redirect{
+			{"http://a.com/two", 301, "http://a.com/one"},
+			{"http://a.com/one", 301, "http://a.com"},
+		}},
+		"http://a.com/loop": {Redirects: []Redirect{
+			{"http://a.com/loop", 302, "http://a.com/loop"},
+		}},
+	}, 1)
+
+	if expected, actual := map[string]int{"2xx": 3, "4xx": 1, "none": 2}, r.Classes(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := []string{"http://a.com/loop", "http://a.com/two"}, r.Flagged(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		" 2xx\t 3\t\n",
+		" http://a.com/two\t 2\t false\t http://a.com/two (301) -> http://a.com/one (301) -> http://a.com\t\n",
+		" http://a.com/loop\t 1\t true\t http://a.com/loop (302) -> http://a.com/loop\t\n",
+	} {
+		if actual := buf.String(); !strings.Contains(actual, v) {
+			t.Errorf("expected: %q, actual: %q", v, actual)
+		}
+	}
+}
+
+func TestStatusReport_NegativeHops(t *testing.T) {
+	t.Parallel()
+
+	r := NewStatusReport(map[string]*Status{
+		"http://a.com": {StatusCode: 200},
+	}, -1)
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := " http://a.com\t 0\t false\t \t\n", buf.String(); !strings.Contains(actual, expected) {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
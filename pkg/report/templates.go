@@ -0,0 +1,242 @@
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+type _escLocalFS struct{}
+
+var _escLocal _escLocalFS
+
+type _escStaticFS struct{}
+
+var _escStatic _escStaticFS
+
+type _escDirectory struct {
+	fs   http.FileSystem
+	name string
+}
+
+type _escFile struct {
+	compressed string
+	size       int64
+	modtime    int64
+	local      string
+	isDir      bool
+
+	once sync.Once
+	data []byte
+	name string
+}
+
+func (_escLocalFS) Open(name string) (http.File, error) {
+	f, present := _escData[path.Clean(name)]
+	if !present {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(f.local)
+}
+
+func (_escStaticFS) prepare(name string) (*_escFile, error) {
+	f, present := _escData[path.Clean(name)]
+	if !present {
+		return nil, os.ErrNotExist
+	}
+	var err error
+	f.once.Do(func() {
+		f.name = path.Base(name)
+		if f.size == 0 {
+			return
+		}
+		var gr *gzip.Reader
+		b64 := base64.NewDecoder(base64.StdEncoding, bytes.NewBufferString(f.compressed))
+		gr, err = gzip.NewReader(b64)
+		if err != nil {
+			return
+		}
+		f.data, err = ioutil.ReadAll(gr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (fs _escStaticFS) Open(name string) (http.File, error) {
+	f, err := fs.prepare(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.File()
+}
+
+func (dir _escDirectory) Open(name string) (http.File, error) {
+	return dir.fs.Open(dir.name + name)
+}
+
+func (f *_escFile) File() (http.File, error) {
+	type httpFile struct {
+		*bytes.Reader
+		*_escFile
+	}
+	return &httpFile{
+		Reader:   bytes.NewReader(f.data),
+		_escFile: f,
+	}, nil
+}
+
+func (f *_escFile) Close() error {
+	return nil
+}
+
+func (f *_escFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, nil
+}
+
+func (f *_escFile) Stat() (os.FileInfo, error) {
+	return f, nil
+}
+
+func (f *_escFile) Name() string {
+	return f.name
+}
+
+func (f *_escFile) Size() int64 {
+	return f.size
+}
+
+func (f *_escFile) Mode() os.FileMode {
+	return 0
+}
+
+func (f *_escFile) ModTime() time.Time {
+	return time.Unix(f.modtime, 0)
+}
+
+func (f *_escFile) IsDir() bool {
+	return f.isDir
+}
+
+func (f *_escFile) Sys() interface{} {
+	return f
+}
+
+// _escFS returns a http.Filesystem for the embedded assets. If useLocal is true,
+// the filesystem's contents are instead used.
+func _escFS(useLocal bool) http.FileSystem {
+	if useLocal {
+		return _escLocal
+	}
+	return _escStatic
+}
+
+// _escDir returns a http.Filesystem for the embedded assets on a given prefix dir.
+// If useLocal is true, the filesystem's contents are instead used.
+func _escDir(useLocal bool, name string) http.FileSystem {
+	if useLocal {
+		return _escDirectory{fs: _escLocal, name: name}
+	}
+	return _escDirectory{fs: _escStatic, name: name}
+}
+
+// _escFSByte returns the named file from the embedded assets. If useLocal is
+// true, the filesystem's contents are instead used.
+func _escFSByte(useLocal bool, name string) ([]byte, error) {
+	if useLocal {
+		f, err := _escLocal.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		return b, err
+	}
+	f, err := _escStatic.prepare(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.data, nil
+}
+
+// _escFSMustByte is the same as _escFSByte, but panics if name is not present.
+func _escFSMustByte(useLocal bool, name string) []byte {
+	b, err := _escFSByte(useLocal, name)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// _escFSString is the string version of _escFSByte.
+func _escFSString(useLocal bool, name string) (string, error) {
+	b, err := _escFSByte(useLocal, name)
+	return string(b), err
+}
+
+// _escFSMustString is the string version of _escFSMustByte.
+func _escFSMustString(useLocal bool, name string) string {
+	return string(_escFSMustByte(useLocal, name))
+}
+
+var _escData = map[string]*_escFile{
+
+	"/templates/html.tmpl": {
+		local:   "pkg/report/templates/html.tmpl",
+		size:    6313,
+		modtime: 1792230036,
+		compressed: `
+H4sIAAAAAAAA/9UZ224iOfadr/BU78yCFApCeqQICkbpdFqKNts9mvRqtertB1NlKCuuy9iukGyGf99z
+fCkMgYaet0kUyhyf+9XlJD+8/3T9+T+/3pBcF2LWSfBBBC2X04iVEQIYzeBRME1JmlOpmJ5GjV70L3FX
+cy3YLJUrIYlkdSV1MrCwTqL0Mz7nVfZMXsiiKnV/QQsunsdE0VL1FZN8MbEbiv+Pjcn52/ppQgoql7wc
+kxErJiStRCXH5M1oNJqQdSc/PyP5yLNbMb7M9ZiUlSyowH1N54LB9rySGZN9oBa0VsDaryZkxTOdg6zh
+8Ecvqz+vtK4KJxK45GdEZ8BGsyfdp4IvQR3BFnpCapplvFyOCahKLlFdJ8qzOAe4qgTPyJssyyw34JQ2
+UqEhdcVLzeSENGA+uECw1BhQgmZzmj4sZdWUGRi8eIu/lv5LRjXtGznTiKo0+joe0wWwQcbgCFYCj4j8
+d/Tzu1G0hyRj36S5tjRZXDbF3CCEdkv0sUGQMZOykpJl4J3xgkul+2nORbZxODppTC42PkgvLpCWl3Wj
+AS10XxuKiyG6fScU5zYUsWoK2MEMyriqBYXsWQgGxPjZX0lajwl+TsgSl+d7WI12WGX8ETXe5+1WP8sH
+mDgdL3d4qJqWoU5zUaUPW8k8MiYCCVaNVqcaMPqGAYYTMAr0Gg3d3pzK1yJMCPtcs0JBCTCbeW19QZSG
+La2gcyaAw469uLXHX28v0wnJXfmFPpcWNIx/tgwy6BtcoPVerNmygjexfVUe604jtohGLVX8wMvMZLFt
+DZeXlwhOBq7hJAPXs7DzYAc732lQAOh0ErQqFVSpaeT0gIZGCMJn//rtTiUY4tnLS3xvd2MErtcgBuHJ
+APE8/m/s94YpzbJXRO3OQcqU8ce9hHbjAN0HLsBPe+j8xgG6G1fBr+j8xkE9teR71TTwA1T3D7yu91A5
++AGq942kmlcl6Raq52kLRWK/sUvnHlsxtVUHIX15kTDOGImvDWS9dmK2EE3oAZ6PUM3POMFQCHw18JbH
+OxiAhsM2D6iSaJaYEkL6O1wgvYUYTJOc08iVFyD9G1frdf0UOTMAdg3lpZHQuQMlM6GYF1nPPsKkSAZ1
+u1lm1iBL4QGtQ8AAE1hlbXl54QtiQ41mJHZc+iqA4sDvtgy0LSIjVssZfMeqgOme45qY8aKfazDJTo1o
+dq+pbpTHmN3x8gEmxQdZFRY2ADZG1ZZ1om2JBg5udfOCzQKX2SyhJJdsMY3egKtu36/XEfoMtEKPURSQ
+BejeLK8epp/R8LrKTHC3sGetBrflHJscYATioOOwJ/I3kApp6AQ7qXM5c27fsPS2hgFytsICnWxiZSMb
+RtUTmMj9E2srdaFL7Ai1LleMyjSPCPT6lOWVMFPeVj5ppFCRjc/CQKZRYRmZA9veiBOehVivw986apMF
+G9CRZAhor+3Bg3wG3BOYbDeC4/ibNnwKrmu8x1HbXnsctW2vpyhgO+oJvnRd9Dimy90TMD81egf1tPr8
+lS5ZUJ5BQzGzw2eWPyhGLqG/o4wty4/VLZYccOyWlam+3qsqO1DmSB5W+k7h72dju6/JTkzOE8TsDqRj
+zWfrJHAceTP9j+GGE/8Ybjjlj+vQjvajjXUzz4+gClaGLfYkdJ+r39thTRM1Ceun304SJ/50iu2vTUbk
+5U6EQWJ6CO7mF5tKgzWCGrFvggg+O3GKDADVTwQksxPBAq3lIMHJ3tTuHuGBswz1ikMD2BJsDQqL0Kpi
+5HiDTRntITMFajCJOZn5kOGZ3NjzD16G57rvMOtKKabVXqPs1l/JJDiB2dwKZ7pKJa/1rNNdNGWKfaPb
+Iy9g6yO8ZJmcVWRKsiptCmhDMbQL+XxvbggqeSVENzI4cTu1exOgvZKSPse1rHSFHT5eVPKGpnmcUqCw
+TM9IK88ArFArFls9kyjXMt8jNLeCjohyjEJZ+Rm+ozVF6QUSovMY3rBvHsG+Ow7NsGSyG6WCpw9RQLnB
+t0qauwVUMY+XTF9p6EvzRrNutLnkiHpkOp0ScztCfiH2yoOMLeCs5YY/7pbjEDs0zXNzjWib3lxoeX/p
+d1XGmfoy/LqNJKsVOnXXZQpMZdZhyCZGNOfdP+HhHBxF8liyonpkB9wCr8aBADBZHfLgmfVzgI3amXTb
+JCw9I/MwPDZAT2AqjVMmhPpiQ/41xjskN1FjEFd0e9seIgS9OP9uKmmqxAXxF9Kt8U7yg6io7j71yB9/
+kGGP9LfAzx48Jk+xqMCZ7LoqAIHB1qQTstaNLH26BemEMsekD48N+nrXUS5YG18BtGeuxiDQFOZjmV3j
+bZmBh2HxK/vET9cT7Pn9SFMw7wVfgvP+1xMag+Mc5JJhE3YG+6YQiIZauREMl++ebzNLsK+ALO+o56yy
+eK+r3sD3Vz2Kf6SiQfGW3HyLdXVXrZi8pop1W+8db4BBkZqCC2TaEIVxjM2Leuwu0kABhG1lZahEbObJ
+p0XXKNgjCRli98H7XNN9om/GeDAgnyAtoCoZySQXop9VK8i/BaGkhjMKWeWwy/XfFRH2bVpXMdB59UkF
+1N0waEx8I2KY+kgW51TlthV1z9swLUgXqH/6CXjEmi4/0oLZGnh/8/nq9u4+2jgKMFAyNkHZMGdYx/6t
+wCPgsdfxRqFpjvMcOw2QG8HWgkln3cNPmLFuRCYDf5iz/5j4PzWmTqupGAAA
+`,
+	},
+
+	"/": {
+		isDir: true,
+		local: "",
+	},
+
+	"/templates": {
+		isDir: true,
+		local: "pkg/report/templates",
+	},
+}
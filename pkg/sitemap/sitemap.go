@@ -0,0 +1,117 @@
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPriority is the priority of a url that doesn't define one.
+const DefaultPriority = 0.5
+
+// maxSize is the maximum size of an uncompressed sitemap, as defined by the
+// sitemaps protocol.
+const maxSize = 50 * 1024 * 1024
+
+var lastModFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// Sitemap is either a urlset, which holds the urls of a site, or a
+// sitemapindex, which holds the locations of other sitemaps.
+type Sitemap struct {
+	URLs     []URL
+	Sitemaps []string
+}
+
+// URL is a url with in a urlset, along with it's metadata.
+type URL struct {
+	Loc      string
+	LastMod  time.Time
+	Priority float64
+}
+
+type document struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc      string `xml:"loc"`
+		LastMod  string `xml:"lastmod"`
+		Priority string `xml:"priority"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// Parse reads a Sitemap from the reader, which can be gzipped.
+func Parse(r io.Reader) (*Sitemap, error) {
+	buf := bufio.NewReader(r)
+	if magic, err := buf.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buf)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid gzip")
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = buf
+	}
+
+	var doc document
+	if err := xml.NewDecoder(io.LimitReader(r, maxSize)).Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "invalid sitemap")
+	}
+
+	res := &Sitemap{}
+	switch doc.XMLName.Local {
+	case "urlset":
+		for _, v := range doc.URLs {
+			if loc := strings.TrimSpace(v.Loc); loc != "" {
+				res.URLs = append(res.URLs, URL{
+					Loc:      loc,
+					LastMod:  parseLastMod(v.LastMod),
+					Priority: parsePriority(v.Priority),
+				})
+			}
+		}
+	case "sitemapindex":
+		for _, v := range doc.Sitemaps {
+			if loc := strings.TrimSpace(v.Loc); loc != "" {
+				res.Sitemaps = append(res.Sitemaps, loc)
+			}
+		}
+	default:
+		return nil, errors.Errorf("%s: unexpected sitemap element", doc.XMLName.Local)
+	}
+	return res, nil
+}
+
+// parseLastMod parses a W3C datetime, returning the zero time if it's not
+// valid.
+func parseLastMod(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, v := range lastModFormats {
+		if t, err := time.Parse(v, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parsePriority parses a priority between 0 and 1, returning the
+// DefaultPriority if it's not valid.
+func parsePriority(s string) float64 {
+	p, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || p < 0 || p > 1 {
+		return DefaultPriority
+	}
+	return p
+}
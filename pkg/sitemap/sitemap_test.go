@@ -0,0 +1,103 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("urlset", func(t *testing.T) {
+		body := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url>
+	<loc> http://url.com/ </loc>
+	<lastmod>2005-01-01</lastmod>
+	<priority>0.8</priority>
+</url>
+<url>
+	<loc>http://url.com/page</loc>
+	<lastmod>2004-12-23T18:00:15+00:00</lastmod>
+	<priority>bad</priority>
+</url>
+<url>
+	<loc>http://url.com/other</loc>
+	<lastmod>yesterday</lastmod>
+</url>
+<url></url>
+</urlset>`
+
+		s, err := Parse(strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []URL{
+			{"http://url.com/", time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC), 0.8},
+			{"http://url.com/page", time.Date(2004, 12, 23, 18, 0, 15, 0, time.UTC), DefaultPriority},
+			{"http://url.com/other", time.Time{}, DefaultPriority},
+		}
+		if len(expected) != len(s.URLs) {
+			t.Fatalf("expected: %v, actual: %v", expected, s.URLs)
+		}
+		for k, v := range expected {
+			actual := s.URLs[k]
+			if v.Loc != actual.Loc || !v.LastMod.Equal(actual.LastMod) || v.Priority != actual.Priority {
+				t.Errorf("expected: %v, actual: %v", v, actual)
+			}
+		}
+	})
+
+	t.Run("sitemapindex", func(t *testing.T) {
+		body := `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>http://url.com/sitemap1.xml.gz</loc></sitemap>
+<sitemap><loc>http://url.com/sitemap2.xml</loc><lastmod>2004-10-01</lastmod></sitemap>
+</sitemapindex>`
+
+		s, err := Parse(strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{
+			"http://url.com/sitemap1.xml.gz",
+			"http://url.com/sitemap2.xml",
+		}
+		if actual := s.Sitemaps; !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(`<urlset><url><loc>http://url.com/</loc></url></urlset>`))
+		gz.Close()
+
+		s, err := Parse(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := 1, len(s.URLs); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []string{
+			``,
+			`<urlset>`,
+			`<html></html>`,
+		} {
+			if _, err := Parse(strings.NewReader(body)); err == nil {
+				t.Errorf("expected error for %q", body)
+			}
+		}
+	})
+}